@@ -25,8 +25,22 @@ import (
 
 // BucketSpec defines the desired state of Bucket
 type BucketSpec struct {
+	// Name is the bucket name in influxdb and defaults to object name.
+	// It allows bucket names that are not valid kubernetes names.
+	Name        string `json:"name,omitempty"`
 	SecondsTTL  int64  `json:"secondsTtl,omitempty"`
 	Description string `json:"description,omitempty"`
+	ConfigName  string `json:"configName,omitempty"`
+	// ConfigRef refers to a Config in the same namespace or to a ClusterConfig
+	// and takes precedence over ConfigName
+	ConfigRef *ConfigReference `json:"configRef,omitempty"`
+	// AdoptExisting allows taking ownership of an existing influxdb bucket
+	// with the same name. Without it such bucket results in conflict
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+	// DeletionPolicy defines what happens to the influxdb bucket when
+	// this object is deleted. Operator default applies when not set
+	//+kubebuilder:validation:Enum=Delete;Retain;Orphan
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
 }
 
 // BucketStatus defines the observed state of Bucket
@@ -35,6 +49,10 @@ type BucketStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 	Message    string             `json:"message,omitempty"`
 	Reason     string             `json:"reason,omitempty"`
+	// ID is the influxdb id of the bucket
+	ID string `json:"id,omitempty"`
+	// OrgID is the influxdb id of the organization the bucket belongs to
+	OrgID string `json:"orgID,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -60,6 +78,20 @@ type BucketList struct {
 	Items           []Bucket `json:"items"`
 }
 
+// GetBucketName returns the name of the bucket in influxdb
+func (r *Bucket) GetBucketName() string {
+	if len(r.Spec.Name) > 0 {
+		return r.Spec.Name
+	}
+
+	return r.Name
+}
+
+// GetConfigRef returns reference to the config used by the object
+func (r *Bucket) GetConfigRef() ConfigReference {
+	return getConfigRef(r.Spec.ConfigRef, r.Spec.ConfigName)
+}
+
 func init() {
 	SchemeBuilder.Register(&Bucket{}, &BucketList{})
 }
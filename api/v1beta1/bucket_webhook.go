@@ -44,7 +44,17 @@ var _ webhook.Defaulter = &Bucket{}
 func (r *Bucket) Default() {
 	bucketlog.Info("default", "name", r.Name)
 
-	// TODO(user): fill in your defaulting logic.
+	if len(r.Spec.Name) == 0 {
+		r.Spec.Name = r.Name
+	}
+
+	if len(r.Spec.ConfigName) == 0 {
+		r.Spec.ConfigName = defaultConfigName
+	}
+
+	if r.Spec.ConfigRef != nil && len(r.Spec.ConfigRef.Kind) == 0 {
+		r.Spec.ConfigRef.Kind = ConfigKind
+	}
 }
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
@@ -56,6 +66,12 @@ var _ webhook.Validator = &Bucket{}
 func (r *Bucket) ValidateCreate() error {
 	bucketlog.Info("validate create", "name", r.Name)
 
+	if protectionPolicy.IsProtectedBucket(r.GetBucketName()) {
+		err := fmt.Errorf("cannot operate on protected bucket %s", r.GetBucketName())
+		bucketlog.Error(err, "forbidden name")
+		return err
+	}
+
 	if r.Spec.SecondsTTL != 0 && r.Spec.SecondsTTL < 3600 {
 		err := fmt.Errorf("secondsTtl needs be either 0 or >= 3600")
 		bucketlog.Error(err, "bucket spec validation error")
@@ -75,12 +91,19 @@ func (r *Bucket) ValidateUpdate(old runtime.Object) error {
 		return err
 	}
 
-	if r.Spec.SecondsTTL != rOld.Spec.SecondsTTL ||
-		r.Spec.Description != rOld.Spec.Description {
-		err := fmt.Errorf("spec fields cannot be updated")
+	if r.GetBucketName() != rOld.GetBucketName() {
+		err := fmt.Errorf("bucket name cannot be updated")
 		bucketlog.Error(err, "fields cannot change")
 		return err
 	}
+
+	// retention and description are reconciled in place on the
+	// influxdb bucket, however, new values still need to be valid
+	if r.Spec.SecondsTTL != 0 && r.Spec.SecondsTTL < 3600 {
+		err := fmt.Errorf("secondsTtl needs be either 0 or >= 3600")
+		bucketlog.Error(err, "bucket spec validation error")
+		return err
+	}
 	return nil
 }
 
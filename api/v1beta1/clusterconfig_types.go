@@ -0,0 +1,81 @@
+/*
+Copyright 2022 kubetrail.io authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterConfigSpec defines the desired state of ClusterConfig
+type ClusterConfigSpec struct {
+	ConfigSpec `json:",inline"`
+	// Namespace holding secrets and config maps referenced by the cluster config
+	Namespace string `json:"namespace"`
+	// AllowedNamespaces lists namespaces whose objects may reference the cluster config
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// NamespaceSelector selects namespaces whose objects may reference the cluster config
+	// in addition to allowed namespaces
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase",description="Status of config"
+//+kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.version",description="Version of influxdb server"
+//+kubebuilder:printcolumn:name="Org ID",type="string",JSONPath=".status.orgID",priority=1
+//+kubebuilder:printcolumn:name="Endpoint",type="string",JSONPath=".status.activeAddr",priority=1
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClusterConfig is the Schema for the clusterconfigs API
+type ClusterConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterConfigSpec `json:"spec,omitempty"`
+	Status ConfigStatus      `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterConfigList contains a list of ClusterConfig
+type ClusterConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterConfig{}, &ClusterConfigList{})
+}
+
+// GetConfig returns a Config in the namespace of the cluster config with the same
+// settings, so that cluster config can be used wherever a Config is
+func (r *ClusterConfig) GetConfig() *Config {
+	return &Config{
+		TypeMeta: metav1.TypeMeta{},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            r.Name,
+			Namespace:       r.Spec.Namespace,
+			UID:             r.UID,
+			ResourceVersion: r.ResourceVersion,
+			Generation:      r.Generation,
+		},
+		Spec:   *r.Spec.ConfigSpec.DeepCopy(),
+		Status: *r.Status.DeepCopy(),
+	}
+}
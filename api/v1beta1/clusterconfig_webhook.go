@@ -0,0 +1,119 @@
+/*
+Copyright 2022 kubetrail.io authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var clusterconfiglog = logf.Log.WithName("clusterconfig-resource")
+
+func (r *ClusterConfig) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	configReader = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-influxdb-kubetrail-io-v1beta1-clusterconfig,mutating=true,failurePolicy=fail,sideEffects=None,groups=influxdb.kubetrail.io,resources=clusterconfigs,verbs=create;update,versions=v1beta1,name=mclusterconfig.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &ClusterConfig{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *ClusterConfig) Default() {
+	clusterconfiglog.Info("default", "name", r.Name)
+
+	// referenced secrets default to the namespace of the cluster config
+	// same as they default to the namespace of a Config
+	config := r.GetConfig()
+	config.Default()
+	r.Spec.ConfigSpec = config.Spec
+}
+
+//+kubebuilder:webhook:path=/validate-influxdb-kubetrail-io-v1beta1-clusterconfig,mutating=false,failurePolicy=fail,sideEffects=None,groups=influxdb.kubetrail.io,resources=clusterconfigs,verbs=create;update,versions=v1beta1,name=vclusterconfig.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ClusterConfig{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterConfig) ValidateCreate() error {
+	clusterconfiglog.Info("validate create", "name", r.Name)
+
+	if err := r.validate(); err != nil {
+		clusterconfiglog.Error(err, "invalid cluster config spec")
+		return err
+	}
+	return nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterConfig) ValidateUpdate(old runtime.Object) error {
+	clusterconfiglog.Info("validate update", "name", r.Name)
+
+	if err := r.validate(); err != nil {
+		clusterconfiglog.Error(err, "invalid cluster config spec")
+		return err
+	}
+
+	oldClusterConfig, ok := old.(*ClusterConfig)
+	if !ok {
+		err := fmt.Errorf("failed to type assert old object")
+		clusterconfiglog.Error(err, "failed to type assert old object")
+		return err
+	}
+
+	// objects in any namespace may reference the cluster config
+	if err := validateTargetChange(
+		&r.Spec.ConfigSpec,
+		&oldClusterConfig.Spec.ConfigSpec,
+		r.Annotations,
+		ConfigReference{Kind: ClusterConfigKind, Name: r.Name},
+		"",
+	); err != nil {
+		clusterconfiglog.Error(err, "invalid cluster config target change")
+		return err
+	}
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterConfig) ValidateDelete() error {
+	clusterconfiglog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+// validate checks namespace settings along with settings common with Config
+func (r *ClusterConfig) validate() error {
+	if len(r.Spec.Namespace) == 0 {
+		return fmt.Errorf("namespace holding referenced secrets is required")
+	}
+
+	if r.Spec.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(r.Spec.NamespaceSelector); err != nil {
+			return fmt.Errorf("invalid namespace selector: %w", err)
+		}
+	}
+
+	return r.Spec.ConfigSpec.validate()
+}
@@ -25,20 +25,128 @@ import (
 
 // ConfigSpec defines the desired state of Config
 type ConfigSpec struct {
-	Addr                 string `json:"addr,omitempty"`
-	OrgName              string `json:"orgName,omitempty"`
+	Addr string `json:"addr,omitempty"`
+	// Addrs lists endpoints of the same influxdb deployment in order of preference.
+	// First healthy endpoint is used. Cannot be set along with addr
+	Addrs   []string `json:"addrs,omitempty"`
+	OrgName string   `json:"orgName,omitempty"`
+	// OrgID is the influxdb id of the organization, such as status.orgID of an
+	// Organization object. When set the organization is found by id and
+	// orgName, if also set, has to match its name
+	OrgID                string `json:"orgID,omitempty"`
 	TokenSecretName      string `json:"tokenSecretName,omitempty"`
 	TokenSecretNamespace string `json:"tokenSecretNamespace,omitempty"`
+	// TokenSecretKey is the key holding the token in the token secret, defaults to token
+	TokenSecretKey string `json:"tokenSecretKey,omitempty"`
+	// BasicAuth authenticates via sign in with username and password
+	// instead of a token
+	BasicAuth *BasicAuthConfig `json:"basicAuth,omitempty"`
+	// AllowBuckets permits Bucket objects to reference this config.
+	// Config named default is always available to Bucket objects
+	AllowBuckets bool `json:"allowBuckets,omitempty"`
+	// TLS settings of connections to influxdb
+	TLS *TLSConfig `json:"tls,omitempty"`
+	// HTTP settings of requests to influxdb
+	HTTP *HTTPConfig `json:"http,omitempty"`
+}
+
+// HTTPConfig defines behavior of requests to influxdb
+type HTTPConfig struct {
+	// Timeout of a request including its retries, defaults to 20s
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// MaxRetries of a request failing with a transient error. Requests that are
+	// not idempotent are only retried on 429 and 503 responses with Retry-After
+	//+kubebuilder:validation:Minimum=0
+	MaxRetries int32 `json:"maxRetries,omitempty"`
+	// RetryInterval between retries of a request, defaults to 1s
+	RetryInterval *metav1.Duration `json:"retryInterval,omitempty"`
+	// ProxyURL of the proxy that requests are routed through
+	ProxyURL string `json:"proxyURL,omitempty"`
+	// Headers added to each request
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// BasicAuthConfig refers to a Secret with username and password keys
+type BasicAuthConfig struct {
+	SecretName string `json:"secretName"`
+	// SecretNamespace defaults to namespace of the config
+	SecretNamespace string `json:"secretNamespace,omitempty"`
+}
+
+// TLSConfig defines tls settings of connections to influxdb. Referenced
+// ConfigMaps and Secrets are read from the namespace of the config
+type TLSConfig struct {
+	// CA refers to a CA bundle to trust in addition to system roots
+	CA *CABundleReference `json:"ca,omitempty"`
+	// ClientCertSecretName refers to a kubernetes.io/tls Secret with
+	// client certificate and key presented to influxdb
+	ClientCertSecretName string `json:"clientCertSecretName,omitempty"`
+	// ServerName overrides server name used to verify server certificate
+	ServerName string `json:"serverName,omitempty"`
+	// InsecureSkipVerify disables verification of server certificate
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// CABundleReference refers to a PEM encoded CA bundle in either a ConfigMap or a Secret
+type CABundleReference struct {
+	ConfigMapName string `json:"configMapName,omitempty"`
+	SecretName    string `json:"secretName,omitempty"`
+	// Key holding the CA bundle, defaults to ca.crt
+	Key string `json:"key,omitempty"`
+}
+
+// ConfigReference refers to a Config in the same namespace or to a ClusterConfig
+type ConfigReference struct {
+	// Kind of the referenced config, defaults to Config
+	//+kubebuilder:validation:Enum=Config;ClusterConfig
+	Kind string `json:"kind,omitempty"`
+	Name string `json:"name"`
+}
+
+// getConfigRef returns config reference falling back to config name
+// and lastly to the default config
+func getConfigRef(configRef *ConfigReference, configName string) ConfigReference {
+	if configRef != nil {
+		ref := *configRef
+		if len(ref.Kind) == 0 {
+			ref.Kind = ConfigKind
+		}
+		return ref
+	}
+
+	if len(configName) == 0 {
+		configName = defaultConfigName
+	}
+
+	return ConfigReference{
+		Kind: ConfigKind,
+		Name: configName,
+	}
 }
 
 // ConfigStatus defines the observed state of Config
 type ConfigStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	Phase      string             `json:"phase,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	Message    string             `json:"message,omitempty"`
+	Reason     string             `json:"reason,omitempty"`
+	// Version of the influxdb server
+	Version string `json:"version,omitempty"`
+	// Health of the influxdb server as reported by its health check
+	Health string `json:"health,omitempty"`
+	// OrgID is the influxdb id of the org named in the spec
+	OrgID string `json:"orgID,omitempty"`
+	// ActiveAddr is the endpoint currently in use
+	ActiveAddr string `json:"activeAddr,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase",description="Status of config"
+//+kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.version",description="Version of influxdb server"
+//+kubebuilder:printcolumn:name="Org ID",type="string",JSONPath=".status.orgID",priority=1
+//+kubebuilder:printcolumn:name="Endpoint",type="string",JSONPath=".status.activeAddr",priority=1
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Config is the Schema for the configs API
 type Config struct {
@@ -61,3 +169,12 @@ type ConfigList struct {
 func init() {
 	SchemeBuilder.Register(&Config{}, &ConfigList{})
 }
+
+// GetAddrs returns endpoints in order of preference
+func (r *ConfigSpec) GetAddrs() []string {
+	if len(r.Addrs) > 0 {
+		return r.Addrs
+	}
+
+	return []string{r.Addr}
+}
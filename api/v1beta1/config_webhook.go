@@ -17,8 +17,15 @@ limitations under the License.
 package v1beta1
 
 import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -26,7 +33,57 @@ import (
 // log is for logging in this package.
 var configlog = logf.Log.WithName("config-resource")
 
+// configReader looks up objects referencing a Config during validation
+var configReader client.Reader
+
+//+kubebuilder:object:generate=false
+
+// SecretNamespacePolicy decides if Config objects in a namespace may
+// reference secrets in another namespace
+type SecretNamespacePolicy func(namespace, secretNamespace string) bool
+
+// secretNamespacePolicy only allows secrets in the namespace of the Config
+// unless set otherwise
+var secretNamespacePolicy SecretNamespacePolicy = func(namespace, secretNamespace string) bool {
+	return namespace == secretNamespace
+}
+
+// SetSecretNamespacePolicy sets policy for cross namespace secret references of Config objects
+func SetSecretNamespacePolicy(policy SecretNamespacePolicy) {
+	if policy != nil {
+		secretNamespacePolicy = policy
+	}
+}
+
+// NewSecretNamespacePolicy returns a policy allowing Config objects in listed
+// namespaces to reference secrets in other namespaces, with * allowing all namespaces.
+// Config objects in other namespaces can only reference secrets in their own namespace
+func NewSecretNamespacePolicy(namespaces []string) SecretNamespacePolicy {
+	allowed := make(map[string]struct{}, len(namespaces))
+	for _, namespace := range namespaces {
+		namespace = strings.TrimSpace(namespace)
+		if len(namespace) == 0 {
+			continue
+		}
+		allowed[namespace] = struct{}{}
+	}
+
+	return func(namespace, secretNamespace string) bool {
+		if namespace == secretNamespace {
+			return true
+		}
+
+		if _, ok := allowed["*"]; ok {
+			return true
+		}
+
+		_, ok := allowed[namespace]
+		return ok
+	}
+}
+
 func (r *Config) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	configReader = mgr.GetClient()
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -42,19 +99,28 @@ var _ webhook.Defaulter = &Config{}
 func (r *Config) Default() {
 	configlog.Info("default", "name", r.Name)
 
-	if len(r.Spec.OrgName) == 0 {
+	if len(r.Spec.OrgName) == 0 && len(r.Spec.OrgID) == 0 {
 		r.Spec.OrgName = defaultOrgName
 	}
 
-	if len(r.Spec.TokenSecretName) == 0 {
-		r.Spec.TokenSecretName = defaultSecretName
-	}
+	// token settings are only defaulted when token is used for authentication
+	if r.Spec.BasicAuth == nil {
+		if len(r.Spec.TokenSecretName) == 0 {
+			r.Spec.TokenSecretName = defaultSecretName
+		}
 
-	if len(r.Spec.TokenSecretNamespace) == 0 {
-		r.Spec.TokenSecretNamespace = r.Namespace
+		if len(r.Spec.TokenSecretNamespace) == 0 {
+			r.Spec.TokenSecretNamespace = r.Namespace
+		}
+
+		if len(r.Spec.TokenSecretKey) == 0 {
+			r.Spec.TokenSecretKey = secretKeyToken
+		}
+	} else if len(r.Spec.BasicAuth.SecretNamespace) == 0 {
+		r.Spec.BasicAuth.SecretNamespace = r.Namespace
 	}
 
-	if len(r.Spec.Addr) == 0 {
+	if len(r.Spec.Addr) == 0 && len(r.Spec.Addrs) == 0 {
 		r.Spec.Addr = defaultAddr
 	}
 }
@@ -68,7 +134,15 @@ var _ webhook.Validator = &Config{}
 func (r *Config) ValidateCreate() error {
 	configlog.Info("validate create", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object creation.
+	if err := r.Spec.validate(); err != nil {
+		configlog.Error(err, "invalid config spec")
+		return err
+	}
+
+	if err := r.validateSecretNamespaces(); err != nil {
+		configlog.Error(err, "invalid secret namespace")
+		return err
+	}
 	return nil
 }
 
@@ -76,7 +150,27 @@ func (r *Config) ValidateCreate() error {
 func (r *Config) ValidateUpdate(old runtime.Object) error {
 	configlog.Info("validate update", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object update.
+	if err := r.Spec.validate(); err != nil {
+		configlog.Error(err, "invalid config spec")
+		return err
+	}
+
+	if err := r.validateSecretNamespaces(); err != nil {
+		configlog.Error(err, "invalid secret namespace")
+		return err
+	}
+
+	oldConfig, ok := old.(*Config)
+	if !ok {
+		err := fmt.Errorf("failed to type assert old object")
+		configlog.Error(err, "failed to type assert old object")
+		return err
+	}
+
+	if err := r.validateTargetChange(oldConfig); err != nil {
+		configlog.Error(err, "invalid config target change")
+		return err
+	}
 	return nil
 }
 
@@ -87,3 +181,241 @@ func (r *Config) ValidateDelete() error {
 	// TODO(user): fill in your validation logic upon object deletion.
 	return nil
 }
+
+// validateSecretNamespaces checks referenced secret namespaces against the policy
+func (r *Config) validateSecretNamespaces() error {
+	namespaces := []string{r.Spec.TokenSecretNamespace}
+	if r.Spec.BasicAuth != nil {
+		namespaces = append(namespaces, r.Spec.BasicAuth.SecretNamespace)
+	}
+
+	for _, namespace := range namespaces {
+		if len(namespace) > 0 && !secretNamespacePolicy(r.Namespace, namespace) {
+			return fmt.Errorf("config in namespace %s cannot reference secrets in namespace %s",
+				r.Namespace, namespace)
+		}
+	}
+
+	return nil
+}
+
+// validateTargetChange rejects changes of addr or org name while objects reference
+// the config, since influxdb resources created by them would be left behind,
+// unless the change is acknowledged via annotation. Endpoints can be added or
+// removed as long as at least one is kept
+func (r *Config) validateTargetChange(old *Config) error {
+	return validateTargetChange(
+		&r.Spec,
+		&old.Spec,
+		r.Annotations,
+		ConfigReference{Kind: ConfigKind, Name: r.Name},
+		r.Namespace,
+	)
+}
+
+// validateTargetChange rejects target changes of a Config or ClusterConfig
+// referenced by objects in the namespace, with empty namespace implying all namespaces
+func validateTargetChange(
+	spec, old *ConfigSpec,
+	annotations map[string]string,
+	ref ConfigReference,
+	namespace string,
+) error {
+	// org id added to or removed from a config naming the same org keeps the target
+	// since controllers check that org id and org name refer to the same org
+	orgIDChanged := len(spec.OrgID) > 0 && len(old.OrgID) > 0 && spec.OrgID != old.OrgID
+	if spec.OrgName == old.OrgName && !orgIDChanged && hasCommonAddr(spec.GetAddrs(), old.GetAddrs()) {
+		return nil
+	}
+
+	if annotations[AnnotationAllowTargetChange] == "true" {
+		return nil
+	}
+
+	if configReader == nil {
+		return nil
+	}
+
+	count, err := countConfigDependents(context.Background(), configReader, ref, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to look up objects referencing the config: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("addr, orgName or orgID cannot change while %d objects reference the config, "+
+			"set annotation %s to true to allow the change", count, AnnotationAllowTargetChange)
+	}
+
+	return nil
+}
+
+// countConfigDependents counts organizations, buckets, tokens and users in the
+// namespace referencing the config
+func countConfigDependents(ctx context.Context, c client.Reader, ref ConfigReference, namespace string) (int, error) {
+	var count int
+
+	organizations := &OrganizationList{}
+	if err := c.List(ctx, organizations, client.InNamespace(namespace)); err != nil {
+		return 0, err
+	}
+	for i := range organizations.Items {
+		if organizations.Items[i].GetConfigRef() == ref {
+			count++
+		}
+	}
+
+	buckets := &BucketList{}
+	if err := c.List(ctx, buckets, client.InNamespace(namespace)); err != nil {
+		return 0, err
+	}
+	for i := range buckets.Items {
+		if buckets.Items[i].GetConfigRef() == ref {
+			count++
+		}
+	}
+
+	tokens := &TokenList{}
+	if err := c.List(ctx, tokens, client.InNamespace(namespace)); err != nil {
+		return 0, err
+	}
+	for i := range tokens.Items {
+		if tokens.Items[i].GetConfigRef() == ref {
+			count++
+		}
+	}
+
+	users := &UserList{}
+	if err := c.List(ctx, users, client.InNamespace(namespace)); err != nil {
+		return 0, err
+	}
+	for i := range users.Items {
+		if users.Items[i].GetConfigRef() == ref {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// validate checks addr, org name or id, token secret key, basic auth, tls and http settings
+func (r *ConfigSpec) validate() error {
+	if len(r.Addr) > 0 && len(r.Addrs) > 0 {
+		return fmt.Errorf("addr and addrs cannot both be set")
+	}
+
+	seen := make(map[string]struct{}, len(r.Addrs))
+	for _, addr := range r.GetAddrs() {
+		if err := validateAddr(addr); err != nil {
+			return err
+		}
+
+		if _, ok := seen[addr]; ok {
+			return fmt.Errorf("addr %s is listed more than once", addr)
+		}
+		seen[addr] = struct{}{}
+	}
+
+	if len(r.OrgName) == 0 && len(r.OrgID) == 0 {
+		return fmt.Errorf("org name or org id is required")
+	}
+
+	if errs := validation.IsConfigMapKey(r.TokenSecretKey); len(r.TokenSecretKey) > 0 && len(errs) > 0 {
+		return fmt.Errorf("invalid token secret key: %s", strings.Join(errs, ", "))
+	}
+
+	if r.BasicAuth != nil && len(r.BasicAuth.SecretName) == 0 {
+		return fmt.Errorf("basic auth needs a secret name")
+	}
+
+	if err := r.validateTLS(); err != nil {
+		return fmt.Errorf("invalid tls settings: %w", err)
+	}
+
+	if err := r.validateHTTP(); err != nil {
+		return fmt.Errorf("invalid http settings: %w", err)
+	}
+
+	return nil
+}
+
+// validateTLS checks that CA bundle is referenced via exactly one source
+func (r *ConfigSpec) validateTLS() error {
+	if r.TLS == nil || r.TLS.CA == nil {
+		return nil
+	}
+
+	if (len(r.TLS.CA.ConfigMapName) > 0) == (len(r.TLS.CA.SecretName) > 0) {
+		return fmt.Errorf("CA bundle needs exactly one of configMapName or secretName")
+	}
+
+	return nil
+}
+
+// validateHTTP checks durations, proxy url and header names
+func (r *ConfigSpec) validateHTTP() error {
+	if r.HTTP == nil {
+		return nil
+	}
+
+	if r.HTTP.Timeout != nil && r.HTTP.Timeout.Duration < 0 {
+		return fmt.Errorf("http timeout needs to be >= 0")
+	}
+
+	if r.HTTP.RetryInterval != nil && r.HTTP.RetryInterval.Duration < 0 {
+		return fmt.Errorf("http retry interval needs to be >= 0")
+	}
+
+	if r.HTTP.MaxRetries < 0 {
+		return fmt.Errorf("http max retries needs to be >= 0")
+	}
+
+	if len(r.HTTP.ProxyURL) > 0 {
+		proxyURL, err := url.Parse(r.HTTP.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy url: %w", err)
+		}
+
+		if len(proxyURL.Host) == 0 {
+			return fmt.Errorf("proxy url needs a host")
+		}
+	}
+
+	for name := range r.HTTP.Headers {
+		if errs := validation.IsHTTPHeaderName(name); len(errs) > 0 {
+			return fmt.Errorf("invalid header name %s: %s", name, strings.Join(errs, ", "))
+		}
+	}
+
+	return nil
+}
+
+// validateAddr checks that addr is an absolute http or https url
+func validateAddr(addr string) error {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return fmt.Errorf("invalid addr: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("addr needs http or https scheme")
+	}
+
+	if len(u.Host) == 0 {
+		return fmt.Errorf("addr needs a host")
+	}
+
+	return nil
+}
+
+// hasCommonAddr checks if two endpoint lists share an endpoint
+func hasCommonAddr(addrs, other []string) bool {
+	for _, addr := range addrs {
+		for _, otherAddr := range other {
+			if strings.TrimSuffix(addr, "/") == strings.TrimSuffix(otherAddr, "/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
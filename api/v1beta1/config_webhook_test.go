@@ -0,0 +1,280 @@
+/*
+Copyright 2022 kubetrail.io authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func init() {
+	// fake client decodes lists via the client-go scheme
+	utilruntime.Must(AddToScheme(scheme.Scheme))
+}
+
+// withConfigReader sets reader of objects referencing configs for the test
+func withConfigReader(t *testing.T, objects ...client.Object) {
+	reader := configReader
+	configReader = fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objects...).
+		Build()
+	t.Cleanup(func() {
+		configReader = reader
+	})
+}
+
+func TestConfigValidateTargetChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		dependents  []client.Object
+		orgName     string
+		oldOrgID    string
+		orgID       string
+		annotations map[string]string
+		wantErr     bool
+	}{
+		{
+			name:    "no dependents",
+			orgName: "other",
+		},
+		{
+			name:    "unchanged target",
+			orgName: "team",
+			dependents: []client.Object{
+				&Bucket{ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: "team"}},
+			},
+		},
+		{
+			name:    "org id added",
+			orgName: "team",
+			orgID:   "team-id",
+			dependents: []client.Object{
+				&Bucket{ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: "team"}},
+			},
+		},
+		{
+			name:     "org id changed",
+			orgName:  "team",
+			oldOrgID: "team-id",
+			orgID:    "other-id",
+			dependents: []client.Object{
+				&Bucket{ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: "team"}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "bucket",
+			orgName: "other",
+			dependents: []client.Object{
+				&Bucket{ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: "team"}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "user",
+			orgName: "other",
+			dependents: []client.Object{
+				&User{ObjectMeta: metav1.ObjectMeta{Name: "alice", Namespace: "team"}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "dependent of other config",
+			orgName: "other",
+			dependents: []client.Object{
+				&Token{
+					ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: "team"},
+					Spec:       TokenSpec{ConfigName: "other"},
+				},
+			},
+		},
+		{
+			name:    "dependent in other namespace",
+			orgName: "other",
+			dependents: []client.Object{
+				&Bucket{ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: "elsewhere"}},
+			},
+		},
+		{
+			name:        "acknowledged change",
+			orgName:     "other",
+			annotations: map[string]string{AnnotationAllowTargetChange: "true"},
+			dependents: []client.Object{
+				&Bucket{ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: "team"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfigReader(t, tt.dependents...)
+
+			old := &Config{
+				ObjectMeta: metav1.ObjectMeta{Name: defaultConfigName, Namespace: "team"},
+				Spec: ConfigSpec{
+					Addr:            "http://influxdb:8086",
+					OrgName:         "team",
+					OrgID:           tt.oldOrgID,
+					TokenSecretName: "token",
+				},
+			}
+			config := old.DeepCopy()
+			config.Annotations = tt.annotations
+			config.Spec.OrgName = tt.orgName
+			config.Spec.OrgID = tt.orgID
+
+			if err := config.ValidateUpdate(old); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClusterConfigValidateTargetChange(t *testing.T) {
+	tests := []struct {
+		name       string
+		dependents []client.Object
+		addr       string
+		wantErr    bool
+	}{
+		{
+			name: "no dependents",
+			addr: "http://standby:8086",
+		},
+		{
+			name: "namespaced config of same name",
+			addr: "http://standby:8086",
+			dependents: []client.Object{
+				&Bucket{
+					ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: "team"},
+					Spec:       BucketSpec{ConfigName: "shared"},
+				},
+			},
+		},
+		{
+			name: "organization in any namespace",
+			addr: "http://standby:8086",
+			dependents: []client.Object{
+				&Organization{
+					ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "team"},
+					Spec: OrganizationSpec{
+						ConfigRef: &ConfigReference{Kind: ClusterConfigKind, Name: "shared"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unchanged target",
+			addr: "http://influxdb:8086",
+			dependents: []client.Object{
+				&Organization{
+					ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "team"},
+					Spec: OrganizationSpec{
+						ConfigRef: &ConfigReference{Kind: ClusterConfigKind, Name: "shared"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfigReader(t, tt.dependents...)
+
+			old := &ClusterConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "shared"},
+				Spec: ClusterConfigSpec{
+					Namespace: "influxdb2-system",
+					ConfigSpec: ConfigSpec{
+						Addr:            "http://influxdb:8086",
+						OrgName:         "shared",
+						TokenSecretName: "token",
+					},
+				},
+			}
+			clusterConfig := old.DeepCopy()
+			clusterConfig.Spec.Addr = tt.addr
+
+			if err := clusterConfig.ValidateUpdate(old); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSecretNamespacePolicy(t *testing.T) {
+	tests := []struct {
+		name            string
+		namespaces      []string
+		namespace       string
+		secretNamespace string
+		want            bool
+	}{
+		{
+			name:            "own namespace",
+			namespaces:      []string{""},
+			namespace:       "team",
+			secretNamespace: "team",
+			want:            true,
+		},
+		{
+			name:            "default",
+			namespaces:      []string{""},
+			namespace:       "team",
+			secretNamespace: "influxdb2-system",
+			want:            false,
+		},
+		{
+			name:            "listed namespace",
+			namespaces:      []string{"monitoring", " team"},
+			namespace:       "team",
+			secretNamespace: "influxdb2-system",
+			want:            true,
+		},
+		{
+			name:            "unlisted namespace",
+			namespaces:      []string{"monitoring"},
+			namespace:       "team",
+			secretNamespace: "influxdb2-system",
+			want:            false,
+		},
+		{
+			name:            "all namespaces",
+			namespaces:      []string{"*"},
+			namespace:       "team",
+			secretNamespace: "influxdb2-system",
+			want:            true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewSecretNamespacePolicy(tt.namespaces)
+			if got := policy(tt.namespace, tt.secretNamespace); got != tt.want {
+				t.Errorf("policy(%q, %q) = %v, want %v",
+					tt.namespace, tt.secretNamespace, got, tt.want)
+			}
+		})
+	}
+}
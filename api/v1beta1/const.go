@@ -1,5 +1,7 @@
 package v1beta1
 
+import "time"
+
 const (
 	defaultOrgName    = "influxdata"
 	defaultConfigName = "default"
@@ -7,6 +9,55 @@ const (
 	defaultAddr       = "http://influxdb2.influxdb2-system.svc.cluster.local"
 )
 
+const (
+	minRotationPeriod = time.Hour
+	// secretKeyToken is the key holding the token in token secret
+	secretKeyToken = "token"
+)
+
+// AnnotationAllowTargetChange on a Config allows changing addr or org name
+// while objects reference the config
+const AnnotationAllowTargetChange = "influxdb.kubetrail.io/allow-target-change"
+
+// ConfigKind const lists kinds that objects can refer to for influxdb settings
+const (
+	ConfigKind        = "Config"
+	ClusterConfigKind = "ClusterConfig"
+)
+
+// UserRole const lists roles users can have in organizations
+const (
+	UserRoleMember = "member"
+	UserRoleOwner  = "owner"
+)
+
+// secretKeyPassword is the default key holding the password in user password secret
+const secretKeyPassword = "password"
+
+// DeletionPolicy const
+const (
+	// DeletionPolicyDelete deletes influxdb resource when object is deleted
+	DeletionPolicyDelete = "Delete"
+	// DeletionPolicyRetain keeps influxdb resource when object is deleted
+	DeletionPolicyRetain = "Retain"
+	// DeletionPolicyOrphan keeps influxdb resource when object is deleted
+	// and also releases kubernetes resources owned by the object, such as
+	// token secret, from garbage collection
+	DeletionPolicyOrphan = "Orphan"
+)
+
+var deletionPolicies = map[string]struct{}{
+	DeletionPolicyDelete: {},
+	DeletionPolicyRetain: {},
+	DeletionPolicyOrphan: {},
+}
+
+// IsValidDeletionPolicy checks if policy is one of known deletion policies
+func IsValidDeletionPolicy(policy string) bool {
+	_, ok := deletionPolicies[policy]
+	return ok
+}
+
 const (
 	PermissionRead  = "read"
 	PermissionWrite = "write"
@@ -61,3 +112,18 @@ var permissionTypes = map[string]struct{}{
 	PermissionRead:  {},
 	PermissionWrite: {},
 }
+
+// SecretKey const lists keys that can be added to token secret
+const (
+	SecretKeyURL    = "url"
+	SecretKeyOrg    = "org"
+	SecretKeyOrgID  = "orgID"
+	SecretKeyBucket = "bucket"
+)
+
+var secretKeys = map[string]struct{}{
+	SecretKeyURL:    {},
+	SecretKeyOrg:    {},
+	SecretKeyOrgID:  {},
+	SecretKeyBucket: {},
+}
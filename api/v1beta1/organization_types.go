@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -25,7 +27,66 @@ import (
 
 // OrganizationSpec defines the desired state of Organization
 type OrganizationSpec struct {
-	ConfigName string `json:"configName,omitempty"`
+	// Name is the organization name in influxdb and defaults to object name.
+	// It allows organization names that are not valid kubernetes names.
+	Name string `json:"name,omitempty"`
+	// Description of the organization in influxdb
+	Description string `json:"description,omitempty"`
+	ConfigName  string `json:"configName,omitempty"`
+	// ConfigRef refers to a Config in the same namespace or to a ClusterConfig
+	// and takes precedence over ConfigName
+	ConfigRef *ConfigReference `json:"configRef,omitempty"`
+	// AdoptExisting allows taking ownership of an existing influxdb organization
+	// with the same name. Without it such organization results in conflict
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+	// DeletionPolicy defines what happens to the influxdb organization when
+	// this object is deleted. Operator default applies when not set
+	//+kubebuilder:validation:Enum=Delete;Retain;Orphan
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+	// Members of the organization
+	Members []OrganizationMember `json:"members,omitempty"`
+	// Owners of the organization
+	Owners []OrganizationMember `json:"owners,omitempty"`
+	// PruneMembers removes members and owners not listed in the spec.
+	// User the operator authenticates as is never removed
+	PruneMembers bool `json:"pruneMembers,omitempty"`
+	// ConfigTemplate generates a Config in the same namespace that points
+	// at this organization once it is ready. Requires the organization to
+	// use a Config rather than a ClusterConfig
+	ConfigTemplate *OrganizationConfigTemplate `json:"configTemplate,omitempty"`
+}
+
+// OrganizationConfigTemplate defines the Config generated for an organization.
+// Generated config reaches influxdb the same way as the config of the organization
+type OrganizationConfigTemplate struct {
+	// Name of the generated Config, defaults to organization object name
+	Name string `json:"name,omitempty"`
+	// AllowBuckets permits Bucket objects to reference the generated config
+	AllowBuckets bool `json:"allowBuckets,omitempty"`
+	// AdminToken issues an org admin Token that the generated config
+	// authenticates with instead of the credentials of the organization config
+	AdminToken *OrganizationAdminToken `json:"adminToken,omitempty"`
+}
+
+// OrganizationAdminToken defines the org admin Token issued for generated config
+type OrganizationAdminToken struct {
+	// SecretName of the secret holding the token, which is also the name
+	// of the Token object. Defaults to config name with -admin-token suffix
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// OrganizationMember refers to an influxdb user either by name
+// or by a User object
+type OrganizationMember struct {
+	// Name of the influxdb user
+	Name string `json:"name,omitempty"`
+	// UserRef refers to a User object in the same namespace
+	UserRef *UserReference `json:"userRef,omitempty"`
+}
+
+// UserReference refers to a User object in the same namespace
+type UserReference struct {
+	Name string `json:"name"`
 }
 
 // OrganizationStatus defines the observed state of Organization
@@ -34,11 +95,20 @@ type OrganizationStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 	Message    string             `json:"message,omitempty"`
 	Reason     string             `json:"reason,omitempty"`
+	// ID is the influxdb id of the organization
+	ID string `json:"id,omitempty"`
+	// OrgID is the influxdb id of the organization and is same as ID.
+	// It allows referencing the organization without a lookup by name
+	OrgID string `json:"orgID,omitempty"`
+	// ConfigName is the name of the Config generated from config template
+	ConfigName string `json:"configName,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase",description="Status of organization"
+//+kubebuilder:printcolumn:name="Org ID",type="string",JSONPath=".status.orgID",priority=1
+//+kubebuilder:printcolumn:name="Config",type="string",JSONPath=".status.configName",priority=1
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Organization is the Schema for the organizations API
@@ -59,6 +129,58 @@ type OrganizationList struct {
 	Items           []Organization `json:"items"`
 }
 
+// GetOrgName returns the name of the organization in influxdb
+func (r *Organization) GetOrgName() string {
+	if len(r.Spec.Name) > 0 {
+		return r.Spec.Name
+	}
+
+	return r.Name
+}
+
+// GetConfigRef returns reference to the config used by the object
+func (r *Organization) GetConfigRef() ConfigReference {
+	return getConfigRef(r.Spec.ConfigRef, r.Spec.ConfigName)
+}
+
+// GetGeneratedConfigName returns the name of the Config generated from
+// config template or an empty string if no template is defined
+func (r *Organization) GetGeneratedConfigName() string {
+	if r.Spec.ConfigTemplate == nil {
+		return ""
+	}
+
+	if len(r.Spec.ConfigTemplate.Name) > 0 {
+		return r.Spec.ConfigTemplate.Name
+	}
+
+	return r.Name
+}
+
+// GetAdminConfigName returns the name of the Config generated for issuing
+// the org admin token or an empty string if no admin token is requested
+func (r *Organization) GetAdminConfigName() string {
+	if r.Spec.ConfigTemplate == nil || r.Spec.ConfigTemplate.AdminToken == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s-admin", r.GetGeneratedConfigName())
+}
+
+// GetAdminTokenSecretName returns the name of the org admin token secret
+// or an empty string if no admin token is requested
+func (r *Organization) GetAdminTokenSecretName() string {
+	if r.Spec.ConfigTemplate == nil || r.Spec.ConfigTemplate.AdminToken == nil {
+		return ""
+	}
+
+	if len(r.Spec.ConfigTemplate.AdminToken.SecretName) > 0 {
+		return r.Spec.ConfigTemplate.AdminToken.SecretName
+	}
+
+	return fmt.Sprintf("%s-admin-token", r.GetGeneratedConfigName())
+}
+
 func init() {
 	SchemeBuilder.Register(&Organization{}, &OrganizationList{})
 }
@@ -18,8 +18,10 @@ package v1beta1
 
 import (
 	"fmt"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -44,9 +46,17 @@ var _ webhook.Defaulter = &Organization{}
 func (r *Organization) Default() {
 	organizationlog.Info("default", "name", r.Name)
 
+	if len(r.Spec.Name) == 0 {
+		r.Spec.Name = r.Name
+	}
+
 	if len(r.Spec.ConfigName) == 0 {
 		r.Spec.ConfigName = defaultConfigName
 	}
+
+	if r.Spec.ConfigRef != nil && len(r.Spec.ConfigRef.Kind) == 0 {
+		r.Spec.ConfigRef.Kind = ConfigKind
+	}
 }
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
@@ -58,12 +68,22 @@ var _ webhook.Validator = &Organization{}
 func (r *Organization) ValidateCreate() error {
 	organizationlog.Info("validate create", "name", r.Name)
 
-	if r.Name == defaultOrgName {
-		err := fmt.Errorf("cannot operate on influxdata")
+	if protectionPolicy.IsProtectedOrg(r.GetOrgName()) {
+		err := fmt.Errorf("cannot operate on protected organization %s", r.GetOrgName())
 		organizationlog.Error(err, "forbidden name")
 		return err
 	}
 
+	if err := r.validateMembers(); err != nil {
+		organizationlog.Error(err, "invalid members")
+		return err
+	}
+
+	if err := r.validateConfigTemplate(); err != nil {
+		organizationlog.Error(err, "invalid config template")
+		return err
+	}
+
 	return nil
 }
 
@@ -71,7 +91,37 @@ func (r *Organization) ValidateCreate() error {
 func (r *Organization) ValidateUpdate(old runtime.Object) error {
 	organizationlog.Info("validate update", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object update.
+	rOld, ok := old.(*Organization)
+	if !ok {
+		err := fmt.Errorf("input type assertion error")
+		organizationlog.Error(err, "failed to type assert input")
+		return err
+	}
+
+	if r.GetOrgName() != rOld.GetOrgName() {
+		err := fmt.Errorf("organization name cannot be updated")
+		organizationlog.Error(err, "fields cannot change")
+		return err
+	}
+
+	if r.Spec.ConfigTemplate != nil && rOld.Spec.ConfigTemplate != nil &&
+		(r.GetGeneratedConfigName() != rOld.GetGeneratedConfigName() ||
+			r.GetAdminTokenSecretName() != rOld.GetAdminTokenSecretName()) {
+		err := fmt.Errorf("names of generated config and admin token cannot be updated")
+		organizationlog.Error(err, "fields cannot change")
+		return err
+	}
+
+	if err := r.validateMembers(); err != nil {
+		organizationlog.Error(err, "invalid members")
+		return err
+	}
+
+	if err := r.validateConfigTemplate(); err != nil {
+		organizationlog.Error(err, "invalid config template")
+		return err
+	}
+
 	return nil
 }
 
@@ -82,3 +132,68 @@ func (r *Organization) ValidateDelete() error {
 	// TODO(user): fill in your validation logic upon object deletion.
 	return nil
 }
+
+// validateMembers checks that members and owners refer to users either
+// by name or by reference and that no user is listed more than once
+func (r *Organization) validateMembers() error {
+	seen := make(map[string]struct{}, len(r.Spec.Members)+len(r.Spec.Owners))
+	for _, members := range [][]OrganizationMember{r.Spec.Members, r.Spec.Owners} {
+		for _, member := range members {
+			if (len(member.Name) > 0) == (member.UserRef != nil) {
+				return fmt.Errorf("members and owners need exactly one of name or userRef")
+			}
+
+			key := fmt.Sprintf("name/%s", member.Name)
+			if member.UserRef != nil {
+				if len(member.UserRef.Name) == 0 {
+					return fmt.Errorf("userRef needs a name")
+				}
+				key = fmt.Sprintf("ref/%s", member.UserRef.Name)
+			}
+
+			if _, ok := seen[key]; ok {
+				return fmt.Errorf("user %s is listed more than once in members and owners", key)
+			}
+			seen[key] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// validateConfigTemplate checks that objects generated from config template
+// have valid names that do not clash with the config of the organization.
+// Generated configs copy credentials and tls settings of the organization config,
+// which therefore needs to be a Config in the namespace of the organization
+func (r *Organization) validateConfigTemplate() error {
+	if r.Spec.ConfigTemplate == nil {
+		return nil
+	}
+
+	if configRef := r.GetConfigRef(); configRef.Kind != ConfigKind {
+		return fmt.Errorf("configTemplate requires a Config in namespace %s, cannot generate configs from %s %s",
+			r.Namespace, configRef.Kind, configRef.Name)
+	}
+
+	configNames := []string{r.GetGeneratedConfigName()}
+	if r.Spec.ConfigTemplate.AdminToken != nil {
+		configNames = append(configNames, r.GetAdminConfigName())
+	}
+
+	for _, name := range append(configNames, r.GetAdminTokenSecretName()) {
+		if len(name) == 0 {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("invalid name %s of generated object: %s", name, strings.Join(errs, ", "))
+		}
+	}
+
+	for _, name := range configNames {
+		if name == r.GetConfigRef().Name {
+			return fmt.Errorf("generated config %s cannot replace config of the organization", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 kubetrail.io authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+//+kubebuilder:object:generate=false
+
+// ProtectionPolicy lists influxdb organizations and buckets that objects
+// can neither create nor delete, such as the bootstrap org and system buckets
+type ProtectionPolicy struct {
+	// OrgNames are names of protected organizations
+	OrgNames []string
+	// BucketPatterns are shell patterns, such as _*, matching names of protected buckets
+	BucketPatterns []string
+}
+
+// protectionPolicy protects default org and buckets with names starting
+// with an underscore unless set otherwise
+var protectionPolicy = ProtectionPolicy{
+	OrgNames:       []string{defaultOrgName},
+	BucketPatterns: []string{"_*"},
+}
+
+// NewProtectionPolicy returns a policy from comma separated lists of
+// org names and bucket name patterns ignoring empty entries
+func NewProtectionPolicy(orgNames, bucketPatterns string) (ProtectionPolicy, error) {
+	policy := ProtectionPolicy{
+		OrgNames:       splitList(orgNames),
+		BucketPatterns: splitList(bucketPatterns),
+	}
+
+	for _, pattern := range policy.BucketPatterns {
+		if _, err := matchName(pattern, ""); err != nil {
+			return ProtectionPolicy{}, fmt.Errorf("invalid bucket pattern %s: %w", pattern, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// SetProtectionPolicy sets policy enforced by Organization and Bucket webhooks
+func SetProtectionPolicy(policy ProtectionPolicy) {
+	protectionPolicy = policy
+}
+
+// IsProtectedOrg checks if organization name is protected
+func (p ProtectionPolicy) IsProtectedOrg(name string) bool {
+	for _, orgName := range p.OrgNames {
+		if orgName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsProtectedBucket checks if bucket name matches any of the protected patterns
+func (p ProtectionPolicy) IsProtectedBucket(name string) bool {
+	for _, pattern := range p.BucketPatterns {
+		if ok, err := matchName(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nameSeparator substitutes slashes during matching, which path.Match treats
+// as separators not matched by wildcards
+const nameSeparator = "\x00"
+
+// matchName matches name against shell pattern. Unlike with file paths, slashes
+// are ordinary characters of bucket names and are matched by wildcards as well
+func matchName(pattern, name string) (bool, error) {
+	return path.Match(
+		strings.ReplaceAll(pattern, "/", nameSeparator),
+		strings.ReplaceAll(name, "/", nameSeparator),
+	)
+}
+
+// splitList splits comma separated list trimming spaces and dropping empty entries
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); len(value) > 0 {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 kubetrail.io authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+)
+
+func TestProtectionPolicyIsProtectedBucket(t *testing.T) {
+	tests := []struct {
+		name           string
+		bucketPatterns string
+		bucketName     string
+		want           bool
+	}{
+		{
+			name:           "system bucket",
+			bucketPatterns: "_*",
+			bucketName:     "_monitoring",
+			want:           true,
+		},
+		{
+			name:           "regular bucket",
+			bucketPatterns: "_*",
+			bucketName:     "telegraf",
+			want:           false,
+		},
+		{
+			name:           "underscore within name",
+			bucketPatterns: "_*",
+			bucketName:     "telegraf_metrics",
+			want:           false,
+		},
+		{
+			name:           "slash within name",
+			bucketPatterns: "_*",
+			bucketName:     "_foo/bar",
+			want:           true,
+		},
+		{
+			name:           "slash within pattern",
+			bucketPatterns: "team/?",
+			bucketName:     "team/a",
+			want:           true,
+		},
+		{
+			name:           "single character wildcard and slash",
+			bucketPatterns: "team?a",
+			bucketName:     "team/a",
+			want:           true,
+		},
+		{
+			name:           "exact name",
+			bucketPatterns: "_*, telegraf",
+			bucketName:     "telegraf",
+			want:           true,
+		},
+		{
+			name:           "prefix pattern",
+			bucketPatterns: "_*,audit-*",
+			bucketName:     "audit-2022",
+			want:           true,
+		},
+		{
+			name:           "empty policy",
+			bucketPatterns: "",
+			bucketName:     "_tasks",
+			want:           false,
+		},
+		{
+			name:           "empty entries",
+			bucketPatterns: ",,",
+			bucketName:     "",
+			want:           false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewProtectionPolicy("", tt.bucketPatterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := policy.IsProtectedBucket(tt.bucketName); got != tt.want {
+				t.Errorf("IsProtectedBucket(%q) = %v, want %v", tt.bucketName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewProtectionPolicyInvalidPattern(t *testing.T) {
+	if _, err := NewProtectionPolicy("", "_*,["); err == nil {
+		t.Error("expected error for malformed bucket pattern")
+	}
+}
@@ -28,13 +28,65 @@ type TokenSpec struct {
 	Permissions []Permission `json:"permissions,omitempty"`
 	SecretName  string       `json:"secretName,omitempty"`
 	ConfigName  string       `json:"configName,omitempty"`
+	// ConfigRef refers to a Config in the same namespace or to a ClusterConfig
+	// and takes precedence over ConfigName
+	ConfigRef *ConfigReference `json:"configRef,omitempty"`
+	// DeletionPolicy defines what happens to the influxdb token when
+	// this object is deleted. Operator default applies when not set
+	//+kubebuilder:validation:Enum=Delete;Retain;Orphan
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+	// Rotation enables periodic replacement of the token
+	Rotation *TokenRotation `json:"rotation,omitempty"`
+	// SecretTemplate customizes the secret holding the token
+	SecretTemplate *SecretTemplate `json:"secretTemplate,omitempty"`
+}
+
+// SecretTemplate defines layout of the token secret in addition to the token key
+type SecretTemplate struct {
+	// Keys to publish along with the token
+	//+kubebuilder:validation:items:Enum=url;org;orgID;bucket
+	Keys []string `json:"keys,omitempty"`
+	// Data maps secret keys to go templates rendered with fields
+	// .Token, .URL, .Org, .OrgID and .Bucket
+	Data map[string]string `json:"data,omitempty"`
+	// Labels added to the secret
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations added to the secret
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// TokenRotation defines automatic rotation of a token
+type TokenRotation struct {
+	// Period after which the token is replaced by a new one
+	Period metav1.Duration `json:"period"`
+	// Overlap during which the replaced token remains valid
+	Overlap metav1.Duration `json:"overlap,omitempty"`
 }
 
 // Permission defines permission for an asset in influxdb
 type Permission struct {
+	// ResourceName scopes permission to a single resource. For buckets
+	// it is the name of an existing influxdb bucket in the org. For orgs
+	// the name of the org of the token scopes permission to that org
 	ResourceName   string `json:"resourceName,omitempty"`
 	ResourceType   string `json:"resourceType,omitempty"`
 	PermissionType string `json:"permissionType,omitempty"`
+	// BucketRef scopes permission to the bucket managed by a Bucket object
+	// in the same namespace. Applies to buckets resource type only
+	BucketRef *BucketReference `json:"bucketRef,omitempty"`
+}
+
+// BucketReference refers to a Bucket object in the same namespace
+type BucketReference struct {
+	Name string `json:"name"`
+}
+
+// PreviousToken is an authorization replaced by a newer one
+type PreviousToken struct {
+	// ID is the influxdb id of the replaced authorization
+	ID string `json:"id"`
+	// ExpiryTime is when the replaced authorization is deleted
+	ExpiryTime metav1.Time `json:"expiryTime"`
 }
 
 // TokenStatus defines the observed state of Token
@@ -44,11 +96,24 @@ type TokenStatus struct {
 	Message    string             `json:"message,omitempty"`
 	Reason     string             `json:"reason,omitempty"`
 	Data       map[string]string  `json:"data,omitempty"`
+	// ID is the influxdb id of the authorization
+	ID string `json:"id,omitempty"`
+	// OrgID is the influxdb id of the organization the authorization belongs to
+	OrgID string `json:"orgID,omitempty"`
+	// PreviousTokens are authorizations replaced by rotation or by permission
+	// changes, which remain valid until their expiry time
+	PreviousTokens []PreviousToken `json:"previousTokens,omitempty"`
+	// LastRotationTime is when the current authorization was issued or its
+	// rotation schedule began
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// NextRotationTime is when the current authorization is due for rotation
+	NextRotationTime *metav1.Time `json:"nextRotationTime,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase",description="Status of token"
+//+kubebuilder:printcolumn:name="Next Rotation",type="date",JSONPath=".status.nextRotationTime",priority=1
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Token is the Schema for the tokens API
@@ -69,6 +134,11 @@ type TokenList struct {
 	Items           []Token `json:"items"`
 }
 
+// GetConfigRef returns reference to the config used by the object
+func (r *Token) GetConfigRef() ConfigReference {
+	return getConfigRef(r.Spec.ConfigRef, r.Spec.ConfigName)
+}
+
 func init() {
 	SchemeBuilder.Register(&Token{}, &TokenList{})
 }
@@ -18,9 +18,12 @@ package v1beta1
 
 import (
 	"fmt"
+	"strings"
+	"text/template"
 
 	"github.com/google/uuid"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -53,6 +56,10 @@ func (r *Token) Default() {
 	if len(r.Spec.ConfigName) == 0 {
 		r.Spec.ConfigName = defaultConfigName
 	}
+
+	if r.Spec.ConfigRef != nil && len(r.Spec.ConfigRef.Kind) == 0 {
+		r.Spec.ConfigRef.Kind = ConfigKind
+	}
 }
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
@@ -64,18 +71,19 @@ var _ webhook.Validator = &Token{}
 func (r *Token) ValidateCreate() error {
 	tokenlog.Info("validate create", "name", r.Name)
 
-	for _, permission := range r.Spec.Permissions {
-		if _, ok := permissionTypes[permission.PermissionType]; !ok {
-			err := fmt.Errorf("invalid permission type")
-			tokenlog.Error(err, "invalid permission type")
-			return err
-		}
+	if err := r.validatePermissions(); err != nil {
+		tokenlog.Error(err, "invalid permissions")
+		return err
+	}
 
-		if _, ok := resourceTypes[permission.ResourceType]; !ok {
-			err := fmt.Errorf("invalid resource type")
-			tokenlog.Error(err, "invalid resource type")
-			return err
-		}
+	if err := r.validateRotation(); err != nil {
+		tokenlog.Error(err, "invalid rotation")
+		return err
+	}
+
+	if err := r.validateSecretTemplate(); err != nil {
+		tokenlog.Error(err, "invalid secret template")
+		return err
 	}
 	return nil
 }
@@ -84,7 +92,20 @@ func (r *Token) ValidateCreate() error {
 func (r *Token) ValidateUpdate(old runtime.Object) error {
 	tokenlog.Info("validate update", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object update.
+	if err := r.validatePermissions(); err != nil {
+		tokenlog.Error(err, "invalid permissions")
+		return err
+	}
+
+	if err := r.validateRotation(); err != nil {
+		tokenlog.Error(err, "invalid rotation")
+		return err
+	}
+
+	if err := r.validateSecretTemplate(); err != nil {
+		tokenlog.Error(err, "invalid secret template")
+		return err
+	}
 	return nil
 }
 
@@ -95,3 +116,88 @@ func (r *Token) ValidateDelete() error {
 	// TODO(user): fill in your validation logic upon object deletion.
 	return nil
 }
+
+// validatePermissions checks permission and resource types and
+// that bucket references are used for buckets only
+func (r *Token) validatePermissions() error {
+	for _, permission := range r.Spec.Permissions {
+		if _, ok := permissionTypes[permission.PermissionType]; !ok {
+			return fmt.Errorf("invalid permission type")
+		}
+
+		if _, ok := resourceTypes[permission.ResourceType]; !ok {
+			return fmt.Errorf("invalid resource type")
+		}
+
+		if permission.BucketRef != nil {
+			if permission.ResourceType != ResourceTypeBuckets {
+				return fmt.Errorf("bucketRef is only valid for %s resource type", ResourceTypeBuckets)
+			}
+
+			if len(permission.BucketRef.Name) == 0 {
+				return fmt.Errorf("bucketRef needs a name")
+			}
+
+			if len(permission.ResourceName) > 0 {
+				return fmt.Errorf("bucketRef and resourceName are mutually exclusive")
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateRotation checks that rotation period is long enough and
+// that the replaced token does not outlive the token replacing it
+func (r *Token) validateRotation() error {
+	if r.Spec.Rotation == nil {
+		return nil
+	}
+
+	if r.Spec.Rotation.Period.Duration < minRotationPeriod {
+		return fmt.Errorf("rotation period needs to be >= %s", minRotationPeriod)
+	}
+
+	if r.Spec.Rotation.Overlap.Duration < 0 ||
+		r.Spec.Rotation.Overlap.Duration > r.Spec.Rotation.Period.Duration {
+		return fmt.Errorf("rotation overlap needs to be between 0 and rotation period")
+	}
+
+	return nil
+}
+
+// validateSecretTemplate checks that secret keys are valid and do not
+// collide with the token key and that data templates parse
+func (r *Token) validateSecretTemplate() error {
+	if r.Spec.SecretTemplate == nil {
+		return nil
+	}
+
+	for _, key := range r.Spec.SecretTemplate.Keys {
+		if _, ok := secretKeys[key]; !ok {
+			return fmt.Errorf("invalid secret key %s", key)
+		}
+	}
+
+	for key, value := range r.Spec.SecretTemplate.Data {
+		if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+			return fmt.Errorf("invalid secret key %s: %s", key, strings.Join(errs, ", "))
+		}
+
+		if key == secretKeyToken {
+			return fmt.Errorf("secret key %s is reserved for the token", key)
+		}
+
+		for _, k := range r.Spec.SecretTemplate.Keys {
+			if key == k {
+				return fmt.Errorf("secret key %s is defined in both keys and data", key)
+			}
+		}
+
+		if _, err := template.New(key).Parse(value); err != nil {
+			return fmt.Errorf("invalid template for secret key %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
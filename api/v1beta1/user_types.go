@@ -0,0 +1,137 @@
+/*
+Copyright 2022 kubetrail.io authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UserSpec defines the desired state of User
+type UserSpec struct {
+	// Name is the user name in influxdb and defaults to object name.
+	// It allows user names that are not valid kubernetes names.
+	Name       string `json:"name,omitempty"`
+	ConfigName string `json:"configName,omitempty"`
+	// ConfigRef refers to a Config in the same namespace or to a ClusterConfig
+	// and takes precedence over ConfigName
+	ConfigRef *ConfigReference `json:"configRef,omitempty"`
+	// PasswordSecret refers to a secret holding the password of the user.
+	// Users without a password can only authenticate with tokens
+	PasswordSecret *PasswordSecretReference `json:"passwordSecret,omitempty"`
+	// Inactive users can neither sign in nor use their tokens
+	Inactive bool `json:"inactive,omitempty"`
+	// Organizations the user is a member or an owner of
+	Organizations []UserMembership `json:"organizations,omitempty"`
+	// AdoptExisting allows taking ownership of an existing influxdb user
+	// with the same name. Without it such user results in conflict
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+	// DeletionPolicy defines what happens to the influxdb user when
+	// this object is deleted. Operator default applies when not set
+	//+kubebuilder:validation:Enum=Delete;Retain;Orphan
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+}
+
+// PasswordSecretReference refers to a key in a Secret in the same namespace
+type PasswordSecretReference struct {
+	Name string `json:"name"`
+	// Key holding the password, defaults to password
+	Key string `json:"key,omitempty"`
+}
+
+// UserMembership grants the user a role in an organization referred to
+// either by influxdb name or by an Organization object
+type UserMembership struct {
+	// Name of the influxdb organization
+	Name string `json:"name,omitempty"`
+	// OrganizationRef refers to an Organization object in the same namespace
+	OrganizationRef *OrganizationReference `json:"organizationRef,omitempty"`
+	// Role of the user in the organization, defaults to member
+	//+kubebuilder:validation:Enum=member;owner
+	Role string `json:"role,omitempty"`
+}
+
+// OrganizationReference refers to an Organization object in the same namespace
+type OrganizationReference struct {
+	Name string `json:"name"`
+}
+
+// UserStatus defines the observed state of User
+type UserStatus struct {
+	Phase      string             `json:"phase,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	Message    string             `json:"message,omitempty"`
+	Reason     string             `json:"reason,omitempty"`
+	// ID is the influxdb id of the user
+	ID string `json:"id,omitempty"`
+	// State of the user in influxdb, either active or inactive
+	State string `json:"state,omitempty"`
+	// PasswordSecretVersion is the resource version of the password secret
+	// last applied to the user
+	PasswordSecretVersion string `json:"passwordSecretVersion,omitempty"`
+	// Memberships are organization roles granted to the user by this object
+	Memberships []UserMembershipStatus `json:"memberships,omitempty"`
+}
+
+// UserMembershipStatus is an organization role granted to the user
+type UserMembershipStatus struct {
+	// OrgID is the influxdb id of the organization
+	OrgID string `json:"orgID"`
+	// Role of the user in the organization
+	Role string `json:"role"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.phase",description="Status of user"
+//+kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state",description="State of influxdb user"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// User is the Schema for the users API
+type User struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UserSpec   `json:"spec,omitempty"`
+	Status UserStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// UserList contains a list of User
+type UserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []User `json:"items"`
+}
+
+// GetUserName returns the name of the user in influxdb
+func (r *User) GetUserName() string {
+	if len(r.Spec.Name) > 0 {
+		return r.Spec.Name
+	}
+
+	return r.Name
+}
+
+// GetConfigRef returns reference to the config used by the object
+func (r *User) GetConfigRef() ConfigReference {
+	return getConfigRef(r.Spec.ConfigRef, r.Spec.ConfigName)
+}
+
+func init() {
+	SchemeBuilder.Register(&User{}, &UserList{})
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 kubetrail.io authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var userlog = logf.Log.WithName("user-resource")
+
+func (r *User) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-influxdb-kubetrail-io-v1beta1-user,mutating=true,failurePolicy=fail,sideEffects=None,groups=influxdb.kubetrail.io,resources=users,verbs=create;update,versions=v1beta1,name=muser.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &User{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *User) Default() {
+	userlog.Info("default", "name", r.Name)
+
+	if len(r.Spec.Name) == 0 {
+		r.Spec.Name = r.Name
+	}
+
+	if len(r.Spec.ConfigName) == 0 {
+		r.Spec.ConfigName = defaultConfigName
+	}
+
+	if r.Spec.ConfigRef != nil && len(r.Spec.ConfigRef.Kind) == 0 {
+		r.Spec.ConfigRef.Kind = ConfigKind
+	}
+
+	if r.Spec.PasswordSecret != nil && len(r.Spec.PasswordSecret.Key) == 0 {
+		r.Spec.PasswordSecret.Key = secretKeyPassword
+	}
+
+	for i := range r.Spec.Organizations {
+		if len(r.Spec.Organizations[i].Role) == 0 {
+			r.Spec.Organizations[i].Role = UserRoleMember
+		}
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-influxdb-kubetrail-io-v1beta1-user,mutating=false,failurePolicy=fail,sideEffects=None,groups=influxdb.kubetrail.io,resources=users,verbs=create;update,versions=v1beta1,name=vuser.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &User{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *User) ValidateCreate() error {
+	userlog.Info("validate create", "name", r.Name)
+
+	if err := r.validateSpec(); err != nil {
+		userlog.Error(err, "invalid user spec")
+		return err
+	}
+
+	return nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *User) ValidateUpdate(old runtime.Object) error {
+	userlog.Info("validate update", "name", r.Name)
+
+	rOld, ok := old.(*User)
+	if !ok {
+		err := fmt.Errorf("input type assertion error")
+		userlog.Error(err, "failed to type assert input")
+		return err
+	}
+
+	if r.GetUserName() != rOld.GetUserName() {
+		err := fmt.Errorf("user name cannot be updated")
+		userlog.Error(err, "fields cannot change")
+		return err
+	}
+
+	if err := r.validateSpec(); err != nil {
+		userlog.Error(err, "invalid user spec")
+		return err
+	}
+
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *User) ValidateDelete() error {
+	userlog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+// validateSpec checks password secret and organization memberships
+func (r *User) validateSpec() error {
+	if len(r.GetUserName()) == 0 {
+		return fmt.Errorf("user name is required")
+	}
+
+	if secret := r.Spec.PasswordSecret; secret != nil {
+		if len(secret.Name) == 0 {
+			return fmt.Errorf("password secret needs a name")
+		}
+
+		if errs := validation.IsConfigMapKey(secret.Key); len(secret.Key) > 0 && len(errs) > 0 {
+			return fmt.Errorf("invalid password secret key: %s", strings.Join(errs, ", "))
+		}
+	}
+
+	seen := make(map[string]struct{}, len(r.Spec.Organizations))
+	for _, membership := range r.Spec.Organizations {
+		if (len(membership.Name) > 0) == (membership.OrganizationRef != nil) {
+			return fmt.Errorf("organizations need exactly one of name or organizationRef")
+		}
+
+		key := fmt.Sprintf("name/%s", membership.Name)
+		if membership.OrganizationRef != nil {
+			if len(membership.OrganizationRef.Name) == 0 {
+				return fmt.Errorf("organizationRef needs a name")
+			}
+			key = fmt.Sprintf("ref/%s", membership.OrganizationRef.Name)
+		}
+
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("organization %s is listed more than once", key)
+		}
+		seen[key] = struct{}{}
+
+		switch membership.Role {
+		case "", UserRoleMember, UserRoleOwner:
+		default:
+			return fmt.Errorf("invalid role %s", membership.Role)
+		}
+	}
+
+	return nil
+}
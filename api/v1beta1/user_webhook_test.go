@@ -0,0 +1,70 @@
+/*
+Copyright 2022 kubetrail.io authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUserValidateUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldName string
+		newName string
+		wantErr bool
+	}{
+		{
+			name:    "unchanged name",
+			oldName: "alice",
+			newName: "alice",
+		},
+		{
+			name:    "defaulted name set explicitly",
+			oldName: "",
+			newName: "sample-user",
+		},
+		{
+			name:    "changed name",
+			oldName: "alice",
+			newName: "bob",
+			wantErr: true,
+		},
+		{
+			name:    "name reset to object name",
+			oldName: "alice",
+			newName: "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := &User{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample-user", Namespace: "team"},
+				Spec:       UserSpec{Name: tt.oldName},
+			}
+			user := old.DeepCopy()
+			user.Spec.Name = tt.newName
+
+			if err := user.ValidateUpdate(old); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -22,23 +22,37 @@ import (
 	"time"
 
 	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
 	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // BucketReconciler reconciles a Bucket object
 type BucketReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Clients is the influxdb client pool shared by reconcilers
+	Clients *ClientPool
+	// DefaultDeletionPolicy applies to objects that do not define deletion policy
+	DefaultDeletionPolicy string
+	// Protection lists influxdb organizations and buckets that are never deleted
+	Protection influxdbv1beta1.ProtectionPolicy
 }
 
 //+kubebuilder:rbac:groups=influxdb.kubetrail.io,resources=buckets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=influxdb.kubetrail.io,resources=buckets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=influxdb.kubetrail.io,resources=buckets/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=influxdb.kubetrail.io,resources=configs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=influxdb.kubetrail.io,resources=clusterconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
@@ -126,7 +140,35 @@ func (r *BucketReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *BucketReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(
+		context.Background(),
+		&influxdbv1beta1.Bucket{},
+		configRefIndexKey,
+		indexConfigRef,
+	); err != nil {
+		return err
+	}
+
+	newList := func() client.ObjectList {
+		return &influxdbv1beta1.BucketList{}
+	}
+
+	// changes in configs and their secrets are propagated to objects referencing them
+	mapFunc := mapConfigToDependents(r.Client, newList)
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&influxdbv1beta1.Bucket{}).
+		Watches(
+			&source.Kind{Type: &influxdbv1beta1.Config{}},
+			handler.EnqueueRequestsFromMapFunc(mapFunc),
+		).
+		Watches(
+			&source.Kind{Type: &influxdbv1beta1.ClusterConfig{}},
+			handler.EnqueueRequestsFromMapFunc(mapFunc),
+		).
+		Watches(
+			&source.Kind{Type: &v1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(mapSecretToConfigDependents(r.Client, newList)),
+		).
 		Complete(r)
 }
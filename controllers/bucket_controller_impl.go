@@ -6,14 +6,13 @@ import (
 	"fmt"
 	"time"
 
-	influxdb "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
 	"github.com/influxdata/influxdb-client-go/v2/api/http"
 	"github.com/influxdata/influxdb-client-go/v2/domain"
 	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
 	v1 "k8s.io/api/core/v1"
 	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
 	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -41,6 +40,8 @@ func (r *BucketReconciler) FinalizeStatus(ctx context.Context, clientObject clie
 			Conditions: object.Status.Conditions,
 			Message:    "object is marked for deletion",
 			Reason:     reasonObjectMarkedForDeletion,
+			ID:         object.Status.ID,
+			OrgID:      object.Status.OrgID,
 		}
 		if err := r.Status().Update(ctx, object); err != nil {
 			reqLogger.Error(err, "failed to update object status")
@@ -68,100 +69,183 @@ func (r *BucketReconciler) FinalizeResources(ctx context.Context, clientObject c
 		return err
 	}
 
+	// protected buckets are retained regardless of deletion policy
+	policy := getDeletionPolicy(object.Spec.DeletionPolicy, r.DefaultDeletionPolicy)
+	protected := r.Protection.IsProtectedBucket(object.GetBucketName())
+	if protected || policy != influxdbv1beta1.DeletionPolicyDelete {
+		for _, condition := range object.Status.Conditions {
+			if condition.Reason == reasonRetainedBucket {
+				return nil
+			}
+		}
+
+		// owner label is deleted so that labels of deleted objects do not pile up
+		// in the org. Retained bucket can thus only be taken over via adoption
+		if err := r.releaseOwnerLabel(ctx, object, req); err != nil {
+			reqLogger.Error(err, "failed to delete owner label")
+			return err
+		}
+
+		message := fmt.Sprintf("retained influxdb bucket per %s deletion policy", policy)
+		if protected {
+			message = "retained protected influxdb bucket"
+		}
+		reqLogger.Info(message)
+		r.Recorder.Event(object, v1.EventTypeNormal, reasonRetainedBucket, message)
+
+		condition := v12.Condition{
+			Type:               conditionTypeInfluxdb,
+			Status:             v12.ConditionTrue,
+			ObservedGeneration: 0,
+			LastTransitionTime: v12.Time{Time: time.Now()},
+			Reason:             reasonRetainedBucket,
+			Message:            message,
+		}
+		object.Status = influxdbv1beta1.BucketStatus{
+			Phase:      object.Status.Phase,
+			Conditions: append(object.Status.Conditions, condition),
+			Message:    message,
+			Reason:     reasonRetainedBucket,
+			ID:         object.Status.ID,
+			OrgID:      object.Status.OrgID,
+		}
+
+		if err := r.Status().Update(ctx, object); err != nil {
+			reqLogger.Error(err, "failed to update object status")
+			return err
+		} else {
+			reqLogger.Info("updated object status")
+			return ObjectUpdated
+		}
+	}
+
+	// buckets created prior to config name being part of the spec
+	// continue to work via default config
+	configRef := object.GetConfigRef()
+
 	// read config with influxdb info
-	config := &influxdbv1beta1.Config{}
-	if err := r.Get(ctx, types.NamespacedName{
-		Namespace: req.Namespace,
-		Name:      configInfluxdb,
-	}, config); err != nil {
+	config, err := getConfig(ctx, r.Client, req.Namespace, configRef)
+	if err != nil {
 		if apimachineryerrors.IsNotFound(err) {
 			reqLogger.Info("influxdb config not found, skipping deleting resources")
 			return nil
 		}
+		if errors.Is(err, ConfigNotAllowed) {
+			reqLogger.Info("influxdb config not allowed, skipping deleting resources")
+			return nil
+		}
 		reqLogger.Error(err, "failed to read influxdb config")
 		return err
 	}
 
-	// read secret with influxdb token
-	secret := &v1.Secret{}
-	if err := r.Get(
-		ctx,
-		types.NamespacedName{
-			Namespace: config.Spec.TokenSecretNamespace,
-			Name:      config.Spec.TokenSecretName,
-		},
-		secret,
-	); err != nil {
-		reqLogger.Error(err, "failed to read influxdb token")
-		return err
+	if !isConfigAllowedForBuckets(configRef, config) {
+		reqLogger.Info("influxdb config does not allow buckets, skipping deleting resources")
+		return nil
 	}
 
-	newClient := influxdb.NewClient(config.Spec.Addr, string(secret.Data[keyToken]))
+	newClient, err := r.Clients.Get(ctx, r.Client, config)
+	if err != nil {
+		reqLogger.Error(err, "failed to create influxdb client")
+		return err
+	}
 	// always close client at the end
 	defer newClient.Close()
 
 	bucketsApi := newClient.BucketsAPI()
-	orgApi := newClient.OrganizationsAPI()
 
-	organization, err := orgApi.FindOrganizationByName(ctx, config.Spec.OrgName)
-	if err != nil {
-		httpErr := &http.Error{
-			StatusCode: 0,
-			Code:       "",
-			Message:    "",
-			Err:        nil,
-			RetryAfter: 0,
-		}
-		if errors.As(err, &httpErr) {
-			if httpErr.StatusCode == 404 {
-				reqLogger.Error(err, "organization not found")
-				return err
-			} else {
-				reqLogger.Error(err,
-					"failed to find organization",
-					"statusCode", httpErr.StatusCode,
-					"message", httpErr.Message,
-				)
+	// bucket id recorded in status is the primary way of identifying the bucket,
+	// lookup by name is only a fallback for objects without a recorded id
+	id := object.Status.ID
+	if len(id) == 0 {
+		orgId := object.Status.OrgID
+		if len(orgId) == 0 {
+			organization, err := newClient.FindConfigOrganization(ctx, config)
+			if err != nil {
+				httpErr := &http.Error{
+					StatusCode: 0,
+					Code:       "",
+					Message:    "",
+					Err:        nil,
+					RetryAfter: 0,
+				}
+				if errors.As(err, &httpErr) {
+					if httpErr.StatusCode == 404 {
+						reqLogger.Error(err, "organization not found")
+						return err
+					} else {
+						reqLogger.Error(err,
+							"failed to find organization",
+							"statusCode", httpErr.StatusCode,
+							"message", httpErr.Message,
+						)
+						return err
+					}
+				} else {
+					reqLogger.Error(err, "failed to find organization")
+					return err
+				}
+			}
+
+			if organization == nil || organization.Id == nil || len(*organization.Id) == 0 {
+				err := fmt.Errorf("nil org pointer or invalid id")
+				reqLogger.Error(err, "failed to get valid org id")
 				return err
 			}
-		} else {
-			reqLogger.Error(err, "failed to find organization")
+
+			orgId = *organization.Id
+		}
+
+		bucket, err := findBucketByName(ctx, bucketsApi, orgId, object.GetBucketName())
+		if err != nil {
+			reqLogger.Error(err, "failed to find buckets in org")
 			return err
 		}
-	}
 
-	if organization == nil || organization.Id == nil || len(*organization.Id) == 0 {
-		err := fmt.Errorf("nil org pointer or invalid id")
-		reqLogger.Error(err, "failed to get valid org id")
-		return err
-	}
+		if bucket != nil && bucket.Id != nil {
+			// never delete a bucket that was not created or adopted by this object
+			owned, err := isBucketOwned(
+				ctx,
+				newClient,
+				*bucket.Id,
+				getOwnerLabelName(object.Name, object.Namespace, string(object.UID)),
+			)
+			if err != nil {
+				reqLogger.Error(err, "failed to check bucket ownership")
+				return err
+			}
 
-	buckets, err := bucketsApi.FindBucketsByOrgID(ctx, *organization.Id)
-	if err != nil || buckets == nil {
-		reqLogger.Error(err, "failed to find buckets in org")
-		return err
-	}
+			if !owned && !hasCondition(object.Status.Conditions, reasonCreatedBucket) {
+				reqLogger.Info("bucket not owned by object, skipping deleting resources")
+				return nil
+			}
 
-	var id string
-	for _, bucket := range *buckets {
-		if bucket.Name == object.Name {
 			id = *bucket.Id
-			break
 		}
 	}
 
 	if len(id) == 0 {
 		reqLogger.Info("bucket not found")
-		return nil
+		return deleteBucketOwnerLabel(ctx, newClient, config, object)
 	}
 
 	if err := bucketsApi.DeleteBucketWithID(ctx, id); err != nil {
+		if isHttpStatusCode(err, 404) {
+			reqLogger.Info("bucket not found")
+			return deleteBucketOwnerLabel(ctx, newClient, config, object)
+		}
 		reqLogger.Error(err, "failed to delete bucket")
 		return err
 	}
 
 	reqLogger.Info("bucket deleted")
 
+	// owner label is only used by the deleted bucket and is removed from the org,
+	// otherwise labels of deleted objects pile up in long-lived orgs
+	if err := deleteBucketOwnerLabel(ctx, newClient, config, object); err != nil {
+		reqLogger.Error(err, "failed to delete owner label")
+		return err
+	}
+
 	var found bool
 	// Update the status of the object if pending
 	for i, condition := range object.Status.Conditions {
@@ -186,6 +270,8 @@ func (r *BucketReconciler) FinalizeResources(ctx context.Context, clientObject c
 			Conditions: append(object.Status.Conditions, condition),
 			Message:    "deleted influxdb bucket",
 			Reason:     reasonDeletedBucket,
+			ID:         object.Status.ID,
+			OrgID:      object.Status.OrgID,
 		}
 	}
 
@@ -299,38 +385,34 @@ func (r *BucketReconciler) ReconcileResources(ctx context.Context, clientObject
 		return err
 	}
 
+	// buckets created prior to config name being part of the spec
+	// continue to work via default config
+	configRef := object.GetConfigRef()
+
 	// read config with influxdb info
-	config := &influxdbv1beta1.Config{}
-	if err := r.Get(ctx, types.NamespacedName{
-		Namespace: req.Namespace,
-		Name:      configInfluxdb,
-	}, config); err != nil {
+	config, err := getConfig(ctx, r.Client, req.Namespace, configRef)
+	if err != nil {
 		reqLogger.Error(err, "failed to read influxdb config")
 		return err
 	}
 
-	// read secret with influxdb token
-	secret := &v1.Secret{}
-	if err := r.Get(
-		ctx,
-		types.NamespacedName{
-			Namespace: config.Spec.TokenSecretNamespace,
-			Name:      config.Spec.TokenSecretName,
-		},
-		secret,
-	); err != nil {
-		reqLogger.Error(err, "failed to read influxdb token")
+	if !isConfigAllowedForBuckets(configRef, config) {
+		err := fmt.Errorf("config %s does not allow buckets", config.Name)
+		reqLogger.Error(err, "failed to use influxdb config")
 		return err
 	}
 
-	newClient := influxdb.NewClient(config.Spec.Addr, string(secret.Data[keyToken]))
+	newClient, err := r.Clients.Get(ctx, r.Client, config)
+	if err != nil {
+		reqLogger.Error(err, "failed to create influxdb client")
+		return err
+	}
 	// always close client at the end
 	defer newClient.Close()
 
 	bucketsApi := newClient.BucketsAPI()
-	orgApi := newClient.OrganizationsAPI()
 
-	organization, err := orgApi.FindOrganizationByName(ctx, config.Spec.OrgName)
+	organization, err := newClient.FindConfigOrganization(ctx, config)
 	if err != nil {
 		httpErr := &http.Error{
 			StatusCode: 0,
@@ -364,59 +446,183 @@ func (r *BucketReconciler) ReconcileResources(ctx context.Context, clientObject
 	}
 
 	var bucketCreated bool
+	var bucketAdopted bool
+	var bucketUpdated bool
 	var found bool
+	var bucket *domain.Bucket
+
+	// influxdb label marking buckets owned by this object
+	ownerLabelName := getOwnerLabelName(object.Name, object.Namespace, string(object.UID))
+
+	// bucket id recorded in status is the primary way of identifying the bucket,
+	// lookup by name is only a fallback for adopting buckets without a recorded id
+	if len(object.Status.ID) > 0 {
+		bucket, err = bucketsApi.FindBucketByID(ctx, object.Status.ID)
+		if err != nil {
+			if !isHttpStatusCode(err, 404) {
+				reqLogger.Error(err, "failed to find bucket by id")
+				return err
+			}
+			reqLogger.Info("bucket not found by id")
+			bucket = nil
+		}
+	}
 
-	if _, err := bucketsApi.CreateBucket(
-		ctx,
-		&domain.Bucket{
-			CreatedAt:   nil,
-			Description: &object.Spec.Description,
-			Id:          nil,
-			Labels:      nil,
-			Links:       nil,
-			Name:        object.Name,
-			OrgID:       organization.Id,
-			RetentionRules: []domain.RetentionRule{
-				{
-					EverySeconds:              object.Spec.SecondsTTL,
-					ShardGroupDurationSeconds: nil,
-					Type:                      "",
-				},
-			},
-			Rp:         nil,
-			SchemaType: nil,
-			Type:       nil,
-			UpdatedAt:  nil,
-		},
-	); err != nil {
-		httpErr := &http.Error{
-			StatusCode: 0,
-			Code:       "",
-			Message:    "",
-			Err:        nil,
-			RetryAfter: 0,
+	if bucket == nil {
+		bucket, err = findBucketByName(ctx, bucketsApi, *organization.Id, object.GetBucketName())
+		if err != nil {
+			reqLogger.Error(err, "failed to find buckets in org")
+			return err
 		}
-		if errors.As(err, &httpErr) && httpErr.StatusCode == 422 {
-			rateLimit(
-				fmt.Sprintf("%s-%s", object.UID, "bucket"),
-				time.Hour*24,
-				func() {
-					reqLogger.Info("bucket exists")
+
+		// bucket found by name is only taken over if it is already owned
+		// by this object or if adoption is explicitly requested
+		if bucket != nil && bucket.Id != nil {
+			owned, err := isBucketOwned(ctx, newClient, *bucket.Id, ownerLabelName)
+			if err != nil {
+				reqLogger.Error(err, "failed to check bucket ownership")
+				return err
+			}
+
+			if !owned && hasCondition(object.Status.Conditions, reasonCreatedBucket) {
+				if err := markBucketOwned(ctx, newClient, *organization.Id, *bucket.Id, ownerLabelName); err != nil {
+					reqLogger.Error(err, "failed to mark bucket as owned")
+					return err
+				}
+				owned = true
+			}
+
+			if !owned {
+				if !object.Spec.AdoptExisting {
+					message := "influxdb bucket exists and is not owned by this object, set spec.adoptExisting to adopt it"
+					if object.Status.Phase != phaseConflict {
+						reqLogger.Info(message)
+						r.Recorder.Event(object, v1.EventTypeWarning, reasonConflictingBucket, message)
+						object.Status.Phase = phaseConflict
+						object.Status.Message = message
+						object.Status.Reason = reasonConflictingBucket
+						if err := r.Status().Update(ctx, object); err != nil {
+							reqLogger.Error(err, "failed to update object status")
+							return err
+						} else {
+							reqLogger.Info("updated object status")
+							return ObjectUpdated
+						}
+					}
+					return nil
+				}
+
+				if err := markBucketOwned(ctx, newClient, *organization.Id, *bucket.Id, ownerLabelName); err != nil {
+					reqLogger.Error(err, "failed to mark bucket as owned")
+					return err
+				}
+
+				reqLogger.Info("bucket adopted")
+				r.Recorder.Event(object, v1.EventTypeNormal, reasonAdoptedBucket, "adopted existing influxdb bucket")
+				bucketAdopted = true
+			}
+		}
+	}
+
+	if bucket == nil {
+		if newBucket, err := bucketsApi.CreateBucket(
+			ctx,
+			&domain.Bucket{
+				CreatedAt:   nil,
+				Description: &object.Spec.Description,
+				Id:          nil,
+				Labels:      nil,
+				Links:       nil,
+				Name:        object.GetBucketName(),
+				OrgID:       organization.Id,
+				RetentionRules: []domain.RetentionRule{
+					{
+						EverySeconds:              object.Spec.SecondsTTL,
+						ShardGroupDurationSeconds: nil,
+						Type:                      "",
+					},
 				},
-			)
+				Rp:         nil,
+				SchemaType: nil,
+				Type:       nil,
+				UpdatedAt:  nil,
+			},
+		); err != nil {
+			httpErr := &http.Error{
+				StatusCode: 0,
+				Code:       "",
+				Message:    "",
+				Err:        nil,
+				RetryAfter: 0,
+			}
+			if errors.As(err, &httpErr) && httpErr.StatusCode == 422 {
+				// bucket was created concurrently by someone else and its
+				// ownership is checked on the next pass
+				reqLogger.Error(err, "bucket exists")
+				return err
+			} else {
+				reqLogger.Error(err, "failed to create bucket")
+				return err
+			}
 		} else {
-			reqLogger.Error(err, "failed to create bucket")
-			return err
+			reqLogger.Info("bucket created")
+			bucketCreated = true
+			bucket = newBucket
+
+			if bucket != nil && bucket.Id != nil {
+				// bucket id gets recorded in status regardless, so failing to
+				// label the bucket does not affect ownership
+				if err := markBucketOwned(ctx, newClient, *organization.Id, *bucket.Id, ownerLabelName); err != nil {
+					reqLogger.Error(err, "failed to mark bucket as owned")
+				}
+			}
 		}
 	} else {
-		reqLogger.Info("bucket created")
-		bucketCreated = true
+		rateLimit(
+			fmt.Sprintf("%s-%s", object.UID, "bucket"),
+			time.Hour*24,
+			func() {
+				reqLogger.Info("bucket exists")
+			},
+		)
+
+		// retention rules and description are mutable on influxdb side,
+		// so bring them back in sync with the spec if they have drifted
+		if getBucketSecondsTTL(bucket) != object.Spec.SecondsTTL ||
+			getBucketDescription(bucket) != object.Spec.Description {
+			bucket.Description = &object.Spec.Description
+			bucket.RetentionRules = []domain.RetentionRule{
+				{
+					EverySeconds:              object.Spec.SecondsTTL,
+					ShardGroupDurationSeconds: getBucketShardGroupDuration(bucket),
+					Type:                      domain.RetentionRuleTypeExpire,
+				},
+			}
+
+			if _, err := bucketsApi.UpdateBucket(ctx, bucket); err != nil {
+				reqLogger.Error(err, "failed to update bucket")
+				return err
+			}
+
+			reqLogger.Info("bucket updated")
+			bucketUpdated = true
+		}
+	}
+
+	// record influxdb ids so that subsequent lookups are direct
+	var idChanged bool
+	if bucket != nil && bucket.Id != nil {
+		if object.Status.ID != *bucket.Id || object.Status.OrgID != *organization.Id {
+			object.Status.ID = *bucket.Id
+			object.Status.OrgID = *organization.Id
+			idChanged = true
+		}
 	}
 
 	// Update the status of the object if pending
 	for i, condition := range object.Status.Conditions {
-		if condition.Reason == reasonCreatedBucket {
-			if bucketCreated {
+		if condition.Reason == reasonCreatedBucket || condition.Reason == reasonAdoptedBucket {
+			if bucketCreated || bucketAdopted {
 				object.Status.Conditions[i].LastTransitionTime = v12.Time{Time: time.Now()}
 			}
 			found = true
@@ -424,19 +630,25 @@ func (r *BucketReconciler) ReconcileResources(ctx context.Context, clientObject
 		}
 	}
 	if !found {
+		reason, message := reasonCreatedBucket, "created influxdb bucket"
+		if bucketAdopted {
+			reason, message = reasonAdoptedBucket, "adopted influxdb bucket"
+		}
 		condition := v12.Condition{
 			Type:               conditionTypeInfluxdb,
 			Status:             v12.ConditionTrue,
 			ObservedGeneration: 0,
 			LastTransitionTime: v12.Time{Time: time.Now()},
-			Reason:             reasonCreatedBucket,
-			Message:            "created influxdb bucket",
+			Reason:             reason,
+			Message:            message,
 		}
 		object.Status = influxdbv1beta1.BucketStatus{
 			Phase:      phaseReady,
 			Conditions: append(object.Status.Conditions, condition),
-			Message:    "created influxdb bucket",
-			Reason:     reasonCreatedBucket,
+			Message:    message,
+			Reason:     reason,
+			ID:         object.Status.ID,
+			OrgID:      object.Status.OrgID,
 		}
 		if err := r.Status().Update(ctx, object); err != nil {
 			reqLogger.Error(err, "failed to update object status")
@@ -445,17 +657,180 @@ func (r *BucketReconciler) ReconcileResources(ctx context.Context, clientObject
 			reqLogger.Info("updated object status")
 			return ObjectUpdated
 		}
-	} else {
-		if bucketCreated {
-			if err := r.Status().Update(ctx, object); err != nil {
-				reqLogger.Error(err, "failed to update object status")
-				return err
-			} else {
-				reqLogger.Info("updated object status")
-				return ObjectUpdated
+	}
+
+	if bucketUpdated {
+		found = false
+		for i, condition := range object.Status.Conditions {
+			if condition.Reason == reasonUpdatedBucket {
+				object.Status.Conditions[i].LastTransitionTime = v12.Time{Time: time.Now()}
+				found = true
+				break
+			}
+		}
+		if !found {
+			object.Status.Conditions = append(object.Status.Conditions, v12.Condition{
+				Type:               conditionTypeInfluxdb,
+				Status:             v12.ConditionTrue,
+				ObservedGeneration: 0,
+				LastTransitionTime: v12.Time{Time: time.Now()},
+				Reason:             reasonUpdatedBucket,
+				Message:            "updated influxdb bucket",
+			})
+		}
+		object.Status.Phase = phaseReady
+		object.Status.Message = "updated influxdb bucket"
+		object.Status.Reason = reasonUpdatedBucket
+	}
+
+	// conflict is resolved once the bucket is owned by this object
+	var conflictResolved bool
+	if object.Status.Phase == phaseConflict {
+		object.Status.Phase = phaseReady
+		object.Status.Message = "resolved influxdb bucket conflict"
+		object.Status.Reason = reasonCreatedBucket
+		if bucketAdopted {
+			object.Status.Reason = reasonAdoptedBucket
+		}
+		conflictResolved = true
+	}
+
+	if bucketCreated || bucketAdopted || bucketUpdated || idChanged || conflictResolved {
+		if err := r.Status().Update(ctx, object); err != nil {
+			reqLogger.Error(err, "failed to update object status")
+			return err
+		} else {
+			reqLogger.Info("updated object status")
+			return ObjectUpdated
+		}
+	}
+
+	return nil
+}
+
+// findBucketByName pages through buckets in the org looking for a bucket
+// with matching name. It returns nil bucket if none is found
+func findBucketByName(ctx context.Context, bucketsApi api.BucketsAPI, orgId, name string) (*domain.Bucket, error) {
+	for offset := 0; ; offset += bucketPageSize {
+		buckets, err := bucketsApi.FindBucketsByOrgID(
+			ctx,
+			orgId,
+			api.PagingWithOffset(offset),
+			api.PagingWithLimit(bucketPageSize),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if buckets == nil {
+			return nil, fmt.Errorf("received nil buckets")
+		}
+
+		for i := range *buckets {
+			if (*buckets)[i].Name == name {
+				return &(*buckets)[i], nil
 			}
 		}
+
+		if len(*buckets) < bucketPageSize {
+			return nil, nil
+		}
+	}
+}
+
+// getBucketShardGroupDuration returns shard group duration of the retention
+// rule of the bucket, if any, so that it is retained when the rule is updated
+func getBucketShardGroupDuration(bucket *domain.Bucket) *int64 {
+	for _, rule := range bucket.RetentionRules {
+		if rule.Type == domain.RetentionRuleTypeExpire || len(rule.Type) == 0 {
+			return rule.ShardGroupDurationSeconds
+		}
 	}
 
 	return nil
 }
+
+// getBucketSecondsTTL returns retention period of the bucket with
+// zero implying infinite retention
+func getBucketSecondsTTL(bucket *domain.Bucket) int64 {
+	for _, rule := range bucket.RetentionRules {
+		if rule.Type == domain.RetentionRuleTypeExpire || len(rule.Type) == 0 {
+			return rule.EverySeconds
+		}
+	}
+
+	return 0
+}
+
+// getBucketDescription returns bucket description treating nil as empty
+func getBucketDescription(bucket *domain.Bucket) string {
+	if bucket.Description == nil {
+		return ""
+	}
+
+	return *bucket.Description
+}
+
+// isConfigAllowedForBuckets checks if bucket objects are allowed to use
+// the config. Permission to set this on the config is controlled via RBAC
+// on config objects allowing bucket RBAC to remain permissive.
+func isConfigAllowedForBuckets(configRef influxdbv1beta1.ConfigReference, config *influxdbv1beta1.Config) bool {
+	if configRef.Kind == influxdbv1beta1.ClusterConfigKind {
+		return config.Spec.AllowBuckets
+	}
+
+	return config.Name == configInfluxdb || config.Spec.AllowBuckets
+}
+
+// releaseOwnerLabel deletes owner label of the object from the org of the bucket,
+// which is skipped when the config is no longer available
+func (r *BucketReconciler) releaseOwnerLabel(ctx context.Context, object *influxdbv1beta1.Bucket, req ctrl.Request) error {
+	configRef := object.GetConfigRef()
+	config, err := getConfig(ctx, r.Client, req.Namespace, configRef)
+	if err != nil {
+		if apimachineryerrors.IsNotFound(err) || errors.Is(err, ConfigNotAllowed) {
+			return nil
+		}
+		return err
+	}
+
+	if !isConfigAllowedForBuckets(configRef, config) {
+		return nil
+	}
+
+	newClient, err := r.Clients.Get(ctx, r.Client, config)
+	if err != nil {
+		return err
+	}
+	// always close client at the end
+	defer newClient.Close()
+
+	return deleteBucketOwnerLabel(ctx, newClient, config, object)
+}
+
+// deleteBucketOwnerLabel deletes owner label of the object from the org of the bucket
+func deleteBucketOwnerLabel(
+	ctx context.Context,
+	newClient *pooledClient,
+	config *influxdbv1beta1.Config,
+	object *influxdbv1beta1.Bucket,
+) error {
+	orgId := object.Status.OrgID
+	if len(orgId) == 0 {
+		organization, err := newClient.FindConfigOrganization(ctx, config)
+		if err != nil {
+			if isHttpStatusCode(err, 404) {
+				return nil
+			}
+			return err
+		}
+		orgId = *organization.Id
+	}
+
+	return deleteOwnerLabel(
+		ctx,
+		newClient,
+		orgId,
+		getOwnerLabelName(object.Name, object.Namespace, string(object.UID)),
+	)
+}
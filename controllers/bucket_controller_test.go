@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+)
+
+// newBucketTest returns bucket reconciler and a bucket in an org of fake influxdb
+func newBucketTest(t *testing.T, server *fakeInfluxdb, config *influxdbv1beta1.Config) (*BucketReconciler, *influxdbv1beta1.Bucket) {
+	object := &influxdbv1beta1.Bucket{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: "team", UID: "metrics-uid"},
+		Spec: influxdbv1beta1.BucketSpec{
+			SecondsTTL: 3600,
+		},
+	}
+
+	r := &BucketReconciler{
+		Client:                newFakeClient(config, server.TokenSecret("team"), object),
+		Scheme:                scheme.Scheme,
+		Recorder:              record.NewFakeRecorder(100),
+		Clients:               NewClientPool(),
+		DefaultDeletionPolicy: influxdbv1beta1.DeletionPolicyDelete,
+	}
+
+	return r, object
+}
+
+// getBucket returns current state of the bucket
+func getBucket(t *testing.T, r *BucketReconciler, object *influxdbv1beta1.Bucket) *influxdbv1beta1.Bucket {
+	current := getObject(t, r.Client, object)
+	if current == nil {
+		t.Fatalf("bucket not found")
+	}
+
+	return current.(*influxdbv1beta1.Bucket)
+}
+
+func TestBucketReconcileAdoption(t *testing.T) {
+	tests := []struct {
+		name          string
+		adoptExisting bool
+		wantPhase     string
+		wantOwned     bool
+	}{
+		{
+			name:      "conflict",
+			wantPhase: phaseConflict,
+		},
+		{
+			name:          "adopted",
+			adoptExisting: true,
+			wantPhase:     phaseReady,
+			wantOwned:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := newFakeInfluxdb(t)
+			orgId := server.CreateOrg("team")
+			bucketId := server.CreateBucket(orgId, "metrics")
+			r, object := newBucketTest(t, server, server.Config("team", "default", "team"))
+			object.Spec.AdoptExisting = tt.adoptExisting
+			if err := r.Update(ctx, object); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			object = getBucket(t, r, object)
+			if object.Status.Phase != tt.wantPhase {
+				t.Errorf("phase = %s, want %s", object.Status.Phase, tt.wantPhase)
+			}
+			if owned := object.Status.ID == bucketId; owned != tt.wantOwned {
+				t.Errorf("status id = %q, want owned %v", object.Status.ID, tt.wantOwned)
+			}
+
+			var wantLabels []string
+			if tt.wantOwned {
+				wantLabels = []string{getOwnerLabelName(object.Name, object.Namespace, string(object.UID))}
+			}
+			if got := server.LabelNames(orgId); !reflect.DeepEqual(got, wantLabels) {
+				t.Errorf("labels = %v, want %v", got, wantLabels)
+			}
+
+			// buckets that were not adopted are never deleted
+			if err := r.Delete(ctx, object); err != nil {
+				t.Fatal(err)
+			}
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			if deleted := server.Bucket(bucketId) == nil; deleted != tt.wantOwned {
+				t.Errorf("bucket deleted = %v, want %v", deleted, tt.wantOwned)
+			}
+		})
+	}
+}
+
+func TestBucketReconcileDeletionPolicy(t *testing.T) {
+	tests := []struct {
+		policy      string
+		wantDeleted bool
+	}{
+		{
+			policy:      influxdbv1beta1.DeletionPolicyDelete,
+			wantDeleted: true,
+		},
+		{
+			policy: influxdbv1beta1.DeletionPolicyRetain,
+		},
+		{
+			policy: influxdbv1beta1.DeletionPolicyOrphan,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			ctx := context.Background()
+			server := newFakeInfluxdb(t)
+			orgId := server.CreateOrg("team")
+			r, object := newBucketTest(t, server, server.Config("team", "default", "team"))
+			object.Spec.DeletionPolicy = tt.policy
+			if err := r.Update(ctx, object); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			object = getBucket(t, r, object)
+			id := object.Status.ID
+			if server.Bucket(id) == nil {
+				t.Fatalf("bucket %q not created", id)
+			}
+
+			if err := r.Delete(ctx, object); err != nil {
+				t.Fatal(err)
+			}
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			if getObject(t, r.Client, object) != nil {
+				t.Fatalf("bucket object not deleted")
+			}
+			if deleted := server.Bucket(id) == nil; deleted != tt.wantDeleted {
+				t.Errorf("bucket deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+
+			// owner label is removed in any case
+			if got := server.LabelNames(orgId); len(got) > 0 {
+				t.Errorf("labels = %v, want none", got)
+			}
+		})
+	}
+}
+
+func TestBucketReconcileFailover(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeInfluxdb(t)
+	server.CreateOrg("team")
+
+	// primary is another endpoint of the same deployment, which can go down
+	var down int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		server.Server.Config.Handler.ServeHTTP(w, req)
+	}))
+	defer primary.Close()
+
+	config := server.Config("team", "default", "team")
+	config.Spec.Addr = ""
+	config.Spec.Addrs = []string{primary.URL, server.URL}
+	r, object := newBucketTest(t, server, config)
+
+	if err := reconcileObject(t, r, r.Client, object); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	object = getBucket(t, r, object)
+	id := object.Status.ID
+	if server.Bucket(id) == nil {
+		t.Fatalf("bucket %q not created", id)
+	}
+
+	atomic.StoreInt32(&down, 1)
+	object.Spec.SecondsTTL = 7200
+	if err := r.Update(ctx, object); err != nil {
+		t.Fatal(err)
+	}
+
+	// request failing on the primary fails over to the next healthy endpoint
+	if err := reconcileObject(t, r, r.Client, object); err == nil {
+		t.Fatalf("expected reconcile to fail while primary is down")
+	}
+	if err := reconcileObject(t, r, r.Client, object); err != nil {
+		t.Fatalf("failed to reconcile after failover: %v", err)
+	}
+
+	bucket := server.Bucket(id)
+	if bucket == nil {
+		t.Fatalf("bucket %q not found", id)
+	}
+	if len(bucket.RetentionRules) != 1 || bucket.RetentionRules[0].EverySeconds != 7200 {
+		t.Errorf("retention rules = %v, want 7200 seconds", bucket.RetentionRules)
+	}
+}
@@ -0,0 +1,442 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	influxdb "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultHTTPTimeout       = 20 * time.Second
+	defaultHTTPRetryInterval = time.Second
+	signOutTimeout           = 5 * time.Second
+	healthCheckTimeout       = 5 * time.Second
+)
+
+// influxdbClient releases connections of the http client on close
+// since influxdb client only does so for http clients it creates
+type influxdbClient struct {
+	influxdb.Client
+	httpClient *http.Client
+	signedIn   bool
+}
+
+// Close ends session, if any, and closes the influxdb client and its idle connections
+func (c *influxdbClient) Close() {
+	if c.signedIn {
+		ctx, cancel := context.WithTimeout(context.Background(), signOutTimeout)
+		_ = c.UsersAPI().SignOut(ctx)
+		cancel()
+	}
+
+	c.Client.Close()
+	c.httpClient.CloseIdleConnections()
+}
+
+// credentials used to authenticate with influxdb, either a token
+// or username and password
+type credentials struct {
+	token    string
+	username string
+	password string
+}
+
+// responseObserver is notified of the outcome of requests sent by a client
+type responseObserver func(req *http.Request, resp *http.Response, err error)
+
+// newInfluxdbClient creates influxdb client per config settings authenticating
+// either with a token or, when basic auth is configured, via sign in. Outcome
+// of requests is passed to the observer, if any
+func newInfluxdbClient(
+	ctx context.Context,
+	c client.Client,
+	config *influxdbv1beta1.Config,
+	observe responseObserver,
+) (influxdb.Client, error) {
+	creds, err := getCredentials(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := getHTTPClient(ctx, c, config, observe)
+	if err != nil {
+		return nil, err
+	}
+
+	options := influxdb.DefaultOptions().SetHTTPClient(httpClient)
+
+	addr, err := selectAddr(ctx, options, config.Spec.GetAddrs())
+	if err != nil {
+		httpClient.CloseIdleConnections()
+		return nil, err
+	}
+
+	newClient := &influxdbClient{
+		Client:     influxdb.NewClientWithOptions(addr, creds.token, options),
+		httpClient: httpClient,
+	}
+
+	if len(creds.username) > 0 {
+		if err := newClient.UsersAPI().SignIn(ctx, creds.username, creds.password); err != nil {
+			newClient.Close()
+			return nil, fmt.Errorf("failed to sign in as %s: %w", creds.username, err)
+		}
+		newClient.signedIn = true
+	}
+
+	return newClient, nil
+}
+
+// selectAddr returns first endpoint reporting healthy status. A single endpoint
+// is returned without checks leaving failures to surface on actual requests
+func selectAddr(ctx context.Context, options *influxdb.Options, addrs []string) (string, error) {
+	if len(addrs) == 1 {
+		return addrs[0], nil
+	}
+
+	failures := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if err := checkHealth(ctx, options, addr); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", addr, err))
+			continue
+		}
+		return addr, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", NoHealthyEndpoint, strings.Join(failures, "; "))
+}
+
+// checkHealth queries health endpoint, which requires no authentication
+func checkHealth(ctx context.Context, options *influxdb.Options, addr string) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	probe := influxdb.NewClientWithOptions(addr, "", options)
+	defer probe.Close()
+
+	health, err := probe.Health(ctx)
+	if err != nil {
+		return err
+	}
+
+	if health.Status != domain.HealthCheckStatusPass {
+		return fmt.Errorf("health status %s", health.Status)
+	}
+
+	return nil
+}
+
+// getCredentials reads token from the token secret of the config or
+// username and password from the basic auth secret when one is configured
+func getCredentials(ctx context.Context, c client.Client, config *influxdbv1beta1.Config) (*credentials, error) {
+	if basicAuth := config.Spec.BasicAuth; basicAuth != nil {
+		namespace := basicAuth.SecretNamespace
+		if len(namespace) == 0 {
+			namespace = config.Namespace
+		}
+
+		secret := &v1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{
+			Namespace: namespace,
+			Name:      basicAuth.SecretName,
+		}, secret); err != nil {
+			return nil, fmt.Errorf("failed to read basic auth secret %s/%s: %w",
+				namespace, basicAuth.SecretName, err)
+		}
+
+		username, password := secret.Data[v1.BasicAuthUsernameKey], secret.Data[v1.BasicAuthPasswordKey]
+		if len(username) == 0 || len(password) == 0 {
+			return nil, fmt.Errorf("basic auth secret %s/%s needs non-empty %s and %s keys",
+				namespace, basicAuth.SecretName, v1.BasicAuthUsernameKey, v1.BasicAuthPasswordKey)
+		}
+
+		return &credentials{
+			username: string(username),
+			password: string(password),
+		}, nil
+	}
+
+	secret := &v1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{
+		Namespace: config.Spec.TokenSecretNamespace,
+		Name:      config.Spec.TokenSecretName,
+	}, secret); err != nil {
+		return nil, fmt.Errorf("failed to read token secret %s/%s: %w",
+			config.Spec.TokenSecretNamespace, config.Spec.TokenSecretName, err)
+	}
+
+	token, err := getConfigToken(config, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &credentials{
+		token: token,
+	}, nil
+}
+
+// getConfigToken returns token found in the token secret under the key defined in the config
+func getConfigToken(config *influxdbv1beta1.Config, secret *v1.Secret) (string, error) {
+	key := config.Spec.TokenSecretKey
+	if len(key) == 0 {
+		key = keyToken
+	}
+
+	token, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("token secret %s/%s does not contain key %s",
+			secret.Namespace, secret.Name, key)
+	}
+
+	if len(token) == 0 {
+		return "", fmt.Errorf("token secret %s/%s contains empty key %s",
+			secret.Namespace, secret.Name, key)
+	}
+
+	return string(token), nil
+}
+
+// getHTTPClient builds http client per tls and http settings of the config
+func getHTTPClient(
+	ctx context.Context,
+	c client.Client,
+	config *influxdbv1beta1.Config,
+	observe responseObserver,
+) (*http.Client, error) {
+	tlsConfig, err := getTLSConfig(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// transport settings mirror those of influxdb client
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	httpClient := &http.Client{
+		Timeout:   defaultHTTPTimeout,
+		Transport: transport,
+	}
+
+	httpConfig := config.Spec.HTTP
+	if httpConfig == nil {
+		if observe != nil {
+			httpClient.Transport = &roundTripper{next: transport, observe: observe}
+		}
+		return httpClient, nil
+	}
+
+	if httpConfig.Timeout != nil && httpConfig.Timeout.Duration > 0 {
+		httpClient.Timeout = httpConfig.Timeout.Duration
+	}
+
+	if len(httpConfig.ProxyURL) > 0 {
+		proxyURL, err := url.Parse(httpConfig.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	retryInterval := defaultHTTPRetryInterval
+	if httpConfig.RetryInterval != nil && httpConfig.RetryInterval.Duration > 0 {
+		retryInterval = httpConfig.RetryInterval.Duration
+	}
+
+	httpClient.Transport = &roundTripper{
+		next:          transport,
+		headers:       httpConfig.Headers,
+		maxRetries:    int(httpConfig.MaxRetries),
+		retryInterval: retryInterval,
+		observe:       observe,
+	}
+
+	return httpClient, nil
+}
+
+// roundTripper adds headers to requests, retries requests failing
+// with transient errors and passes final outcome to the observer
+type roundTripper struct {
+	next          http.RoundTripper
+	headers       map[string]string
+	maxRetries    int
+	retryInterval time.Duration
+	observe       responseObserver
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.roundTrip(req)
+	if t.observe != nil {
+		t.observe(req, resp, err)
+	}
+
+	return resp, err
+}
+
+// roundTrip sends request retrying it on transient errors
+func (t *roundTripper) roundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.headers) > 0 {
+		req = req.Clone(req.Context())
+		for k, v := range t.headers {
+			req.Header.Set(k, v)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt >= t.maxRetries || req.Context().Err() != nil || !isRetryable(req, resp, err) {
+			return resp, err
+		}
+
+		// request body can only be replayed if it can be obtained again
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(t.retryInterval):
+		}
+	}
+}
+
+// isRetryable checks if request outcome is a transient error. Requests that
+// are not safe to repeat, such as POST, are only retried if influxdb did not
+// process them, i.e. when it rate limits or is unavailable and asks for a retry
+func isRetryable(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return isIdempotent(req.Method)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return isIdempotent(req.Method) || len(resp.Header.Get("Retry-After")) > 0
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(req.Method)
+	default:
+		return false
+	}
+}
+
+// isIdempotent checks if repeating a request of given method is safe
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// getTLSConfig builds tls config from referenced CA bundle and client certificate
+// returning nil when config does not define tls settings
+func getTLSConfig(ctx context.Context, c client.Client, config *influxdbv1beta1.Config) (*tls.Config, error) {
+	if config.Spec.TLS == nil {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         config.Spec.TLS.ServerName,
+		InsecureSkipVerify: config.Spec.TLS.InsecureSkipVerify,
+	}
+
+	if ca := config.Spec.TLS.CA; ca != nil {
+		key := ca.Key
+		if len(key) == 0 {
+			key = keyCABundle
+		}
+
+		var bundle []byte
+		switch {
+		case len(ca.ConfigMapName) > 0:
+			configMap := &v1.ConfigMap{}
+			if err := c.Get(ctx, types.NamespacedName{
+				Namespace: config.Namespace,
+				Name:      ca.ConfigMapName,
+			}, configMap); err != nil {
+				return nil, fmt.Errorf("failed to read CA bundle config map: %w", err)
+			}
+			bundle = []byte(configMap.Data[key])
+		case len(ca.SecretName) > 0:
+			secret := &v1.Secret{}
+			if err := c.Get(ctx, types.NamespacedName{
+				Namespace: config.Namespace,
+				Name:      ca.SecretName,
+			}, secret); err != nil {
+				return nil, fmt.Errorf("failed to read CA bundle secret: %w", err)
+			}
+			bundle = secret.Data[key]
+		default:
+			return nil, fmt.Errorf("CA bundle reference needs a config map or a secret name")
+		}
+
+		if len(bundle) == 0 {
+			return nil, fmt.Errorf("CA bundle not found under key %s", key)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("failed to parse CA bundle")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(config.Spec.TLS.ClientCertSecretName) > 0 {
+		secret := &v1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{
+			Namespace: config.Namespace,
+			Name:      config.Spec.TLS.ClientCertSecretName,
+		}, secret); err != nil {
+			return nil, fmt.Errorf("failed to read client certificate secret: %w", err)
+		}
+
+		certificate, err := tls.X509KeyPair(secret.Data[v1.TLSCertKey], secret.Data[v1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
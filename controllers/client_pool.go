@@ -0,0 +1,299 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	influxdb "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// clientMaxAge bounds how long a client, its session and memoized org are reused
+	clientMaxAge = 10 * time.Minute
+)
+
+// ClientPool shares influxdb clients among reconcilers. Clients are keyed by
+// config uid and replaced when the config spec or the secrets it references change
+type ClientPool struct {
+	mu      sync.Mutex
+	entries map[types.UID]*clientPoolEntry
+}
+
+// clientPoolEntry is a pooled client along with the state it was built from
+type clientPoolEntry struct {
+	client influxdb.Client
+	// generation of the config, which unlike its resource version
+	// does not change on status updates
+	generation int64
+	secretHash string
+	created    time.Time
+	// refs counts clients handed out and not yet closed
+	refs int
+	// stale entries are closed once all references are released
+	stale bool
+	// failover, if set, drops the entry so that the next client selects
+	// a healthy endpoint among those of the config
+	failover func()
+
+	orgMu        sync.Mutex
+	organization *domain.Organization
+}
+
+// NewClientPool creates an empty client pool
+func NewClientPool() *ClientPool {
+	return &ClientPool{
+		entries: make(map[types.UID]*clientPoolEntry),
+	}
+}
+
+// pooledClient is an influxdb client handed out by the pool. Closing it
+// releases it back to the pool
+type pooledClient struct {
+	influxdb.Client
+	pool  *ClientPool
+	entry *clientPoolEntry
+	once  sync.Once
+}
+
+// Close releases client back to the pool
+func (c *pooledClient) Close() {
+	c.once.Do(func() {
+		if c.pool == nil {
+			c.entry.client.Close()
+			return
+		}
+		c.pool.release(c.entry)
+	})
+}
+
+// FindConfigOrganization returns org referenced by the config, which is looked
+// up once per pooled client until requests report a resource not found
+func (c *pooledClient) FindConfigOrganization(ctx context.Context, config *influxdbv1beta1.Config) (*domain.Organization, error) {
+	// lock is not held during the lookup since its response is observed by the entry
+	c.entry.orgMu.Lock()
+	if c.entry.organization != nil {
+		organization := *c.entry.organization
+		c.entry.orgMu.Unlock()
+		return &organization, nil
+	}
+	c.entry.orgMu.Unlock()
+
+	organization, err := findConfigOrganization(ctx, c.OrganizationsAPI(), &config.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if organization == nil || organization.Id == nil || len(*organization.Id) == 0 {
+		return nil, fmt.Errorf("nil org pointer or invalid id")
+	}
+
+	c.entry.orgMu.Lock()
+	c.entry.organization = organization
+	c.entry.orgMu.Unlock()
+
+	memoized := *organization
+	return &memoized, nil
+}
+
+// observe fails over to another endpoint when the one in use cannot be reached
+// or fails with a server error. Memoized org of the entry is dropped when a request
+// reports a resource not found, since the org may have been deleted or recreated
+func (e *clientPoolEntry) observe(req *http.Request, resp *http.Response, err error) {
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		// requests cancelled by the caller say nothing about the endpoint
+		if e.failover != nil && req.Context().Err() == nil {
+			e.failover()
+		}
+		return
+	}
+
+	if resp.StatusCode != http.StatusNotFound {
+		return
+	}
+
+	e.orgMu.Lock()
+	e.organization = nil
+	e.orgMu.Unlock()
+}
+
+// Get returns a client for the config reusing a pooled one when config spec and
+// its secrets are unchanged. Returned client needs to be closed after use. A nil
+// pool hands out clients that are not shared
+func (p *ClientPool) Get(ctx context.Context, c client.Client, config *influxdbv1beta1.Config) (*pooledClient, error) {
+	if p == nil {
+		newClient, err := newInfluxdbClient(ctx, c, config, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &pooledClient{Client: newClient, entry: &clientPoolEntry{client: newClient}}, nil
+	}
+
+	secretHash, err := getConfigSecretHash(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	entry, ok := p.entries[config.UID]
+	if ok && entry.generation == config.Generation &&
+		entry.secretHash == secretHash &&
+		time.Since(entry.created) < clientMaxAge {
+		entry.refs++
+		p.mu.Unlock()
+		return &pooledClient{Client: entry.client, pool: p, entry: entry}, nil
+	}
+	p.mu.Unlock()
+
+	entry = &clientPoolEntry{
+		generation: config.Generation,
+		secretHash: secretHash,
+		created:    time.Now(),
+		refs:       1,
+	}
+	if len(config.Spec.GetAddrs()) > 1 {
+		uid := config.UID
+		entry.failover = func() {
+			p.invalidateEntry(uid, entry)
+		}
+	}
+
+	// client is created without holding the lock since it involves network calls
+	newClient, err := newInfluxdbClient(ctx, c, config, entry.observe)
+	if err != nil {
+		return nil, err
+	}
+	entry.client = newClient
+
+	p.mu.Lock()
+	p.evict(config.UID)
+	p.entries[config.UID] = entry
+	p.pruneExpired()
+	p.mu.Unlock()
+
+	return &pooledClient{Client: entry.client, pool: p, entry: entry}, nil
+}
+
+// Invalidate drops pooled client of the config, for instance after
+// requests failed due to an expired session
+func (p *ClientPool) Invalidate(uid types.UID) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.evict(uid)
+}
+
+// invalidateEntry drops the entry if it is still the pooled one of the uid
+func (p *ClientPool) invalidateEntry(uid types.UID, entry *clientPoolEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.entries[uid] == entry {
+		p.evict(uid)
+	}
+}
+
+// release decrements references of the entry closing it when stale and unused
+func (p *ClientPool) release(entry *clientPoolEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry.refs--
+	if entry.stale && entry.refs == 0 {
+		entry.client.Close()
+	}
+}
+
+// evict removes entry of the uid, if any, closing it when unused. Lock must be held
+func (p *ClientPool) evict(uid types.UID) {
+	entry, ok := p.entries[uid]
+	if !ok {
+		return
+	}
+
+	delete(p.entries, uid)
+	entry.stale = true
+	if entry.refs == 0 {
+		entry.client.Close()
+	}
+}
+
+// pruneExpired evicts entries past their max age, such as those of deleted
+// configs. Lock must be held
+func (p *ClientPool) pruneExpired() {
+	for uid, entry := range p.entries {
+		if time.Since(entry.created) >= clientMaxAge {
+			p.evict(uid)
+		}
+	}
+}
+
+// getConfigSecretHash hashes data of secrets and config maps referenced by the config
+func getConfigSecretHash(ctx context.Context, c client.Client, config *influxdbv1beta1.Config) (string, error) {
+	hash := sha256.New()
+
+	refs := getConfigSecretRefs(config)
+	sort.Strings(refs)
+	for _, ref := range refs {
+		namespace, name, err := splitSecretRefIndexValue(ref)
+		if err != nil {
+			return "", err
+		}
+
+		secret := &v1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); err != nil {
+			return "", fmt.Errorf("failed to read secret %s: %w", ref, err)
+		}
+
+		_, _ = fmt.Fprintf(hash, "secret/%s\n", ref)
+		for _, key := range sortedKeys(secret.Data) {
+			_, _ = fmt.Fprintf(hash, "%s=%x\n", key, secret.Data[key])
+		}
+	}
+
+	if tls := config.Spec.TLS; tls != nil && tls.CA != nil && len(tls.CA.ConfigMapName) > 0 {
+		configMap := &v1.ConfigMap{}
+		if err := c.Get(ctx, types.NamespacedName{
+			Namespace: config.Namespace,
+			Name:      tls.CA.ConfigMapName,
+		}, configMap); err != nil {
+			return "", fmt.Errorf("failed to read CA bundle config map: %w", err)
+		}
+
+		_, _ = fmt.Fprintf(hash, "configmap/%s/%s\n", config.Namespace, tls.CA.ConfigMapName)
+		keys := make([]string, 0, len(configMap.Data))
+		for key := range configMap.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			_, _ = fmt.Fprintf(hash, "%s=%x\n", key, configMap.Data[key])
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// sortedKeys returns keys of secret data in sorted order
+func sortedKeys(data map[string][]byte) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+)
+
+func TestClientPoolGet(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeInfluxdb(t)
+	config := server.Config("team", "default", "team")
+	secret := server.TokenSecret("team")
+	c := newFakeClient(secret)
+	pool := NewClientPool()
+
+	get := func() *pooledClient {
+		pooled, err := pool.Get(ctx, c, config)
+		if err != nil {
+			t.Fatalf("failed to get client: %v", err)
+		}
+		pooled.Close()
+		return pooled
+	}
+
+	first := get()
+
+	// status updates change resource version but not generation
+	config.ResourceVersion = "2"
+	if got := get(); got.entry != first.entry {
+		t.Errorf("client replaced after status update")
+	}
+
+	config.Generation++
+	second := get()
+	if second.entry == first.entry {
+		t.Errorf("client reused after spec update")
+	}
+
+	secret.Data[keyToken] = []byte("rotated-token")
+	if err := c.Update(ctx, secret); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+	if got := get(); got.entry == second.entry {
+		t.Errorf("client reused after secret update")
+	}
+}
+
+func TestPooledClientFindConfigOrganization(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeInfluxdb(t)
+	orgId := server.CreateOrg("team")
+	config := server.Config("team", "default", "team")
+	pool := NewClientPool()
+
+	pooled, err := pool.Get(ctx, newFakeClient(server.TokenSecret("team")), config)
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	defer pooled.Close()
+
+	organization, err := pooled.FindConfigOrganization(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to find org: %v", err)
+	}
+	if *organization.Id != orgId {
+		t.Errorf("org id = %s, want %s", *organization.Id, orgId)
+	}
+
+	// org recreated out of band is only picked up once a request reports not found
+	if err := pooled.OrganizationsAPI().DeleteOrganizationWithID(ctx, orgId); err != nil {
+		t.Fatalf("failed to delete org: %v", err)
+	}
+	newOrgId := server.CreateOrg("team")
+
+	organization, err = pooled.FindConfigOrganization(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to find org: %v", err)
+	}
+	if *organization.Id != orgId {
+		t.Errorf("org id = %s, want memoized %s", *organization.Id, orgId)
+	}
+
+	if _, err := pooled.OrganizationsAPI().FindOrganizationByID(ctx, orgId); err == nil {
+		t.Fatalf("expected deleted org not to be found")
+	}
+
+	organization, err = pooled.FindConfigOrganization(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to find org: %v", err)
+	}
+	if *organization.Id != newOrgId {
+		t.Errorf("org id = %s, want %s", *organization.Id, newOrgId)
+	}
+}
+
+func TestClientPoolFailover(t *testing.T) {
+	ctx := context.Background()
+	primary, standby := newFakeInfluxdb(t), newFakeInfluxdb(t)
+	config := primary.Config("team", "default", "team")
+	config.Spec.Addr = ""
+	config.Spec.Addrs = []string{primary.URL, standby.URL}
+	c := newFakeClient(primary.TokenSecret("team"))
+	pool := NewClientPool()
+
+	get := func() *pooledClient {
+		pooled, err := pool.Get(ctx, c, config)
+		if err != nil {
+			t.Fatalf("failed to get client: %v", err)
+		}
+		pooled.Close()
+		return pooled
+	}
+
+	first := get()
+	if got := first.ServerURL(); got != primary.URL {
+		t.Fatalf("server url = %s, want primary %s", got, primary.URL)
+	}
+
+	// client errors are no reason to fail over
+	if _, err := first.OrganizationsAPI().FindOrganizationByName(ctx, "missing"); err == nil {
+		t.Fatalf("expected missing org not to be found")
+	}
+	if got := get(); got.entry != first.entry {
+		t.Fatalf("client replaced after client error")
+	}
+
+	primary.SetHealth(domain.HealthCheckStatusFail)
+	primary.SetFailures(func(req *http.Request) int {
+		return http.StatusInternalServerError
+	})
+	if _, err := first.OrganizationsAPI().FindOrganizationByName(ctx, "team"); err == nil {
+		t.Fatalf("expected failing primary to return error")
+	}
+
+	second := get()
+	if got := second.ServerURL(); got != standby.URL {
+		t.Errorf("server url = %s, want standby %s", got, standby.URL)
+	}
+}
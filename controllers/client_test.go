@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		statusCode int
+		retryAfter string
+		err        error
+		want       bool
+	}{
+		{
+			name:   "get connection error",
+			method: http.MethodGet,
+			err:    errors.New("connection refused"),
+			want:   true,
+		},
+		{
+			name:   "delete connection error",
+			method: http.MethodDelete,
+			err:    errors.New("connection refused"),
+			want:   true,
+		},
+		{
+			name:   "post connection error",
+			method: http.MethodPost,
+			err:    errors.New("connection refused"),
+			want:   false,
+		},
+		{
+			name:       "get ok",
+			method:     http.MethodGet,
+			statusCode: http.StatusOK,
+			want:       false,
+		},
+		{
+			name:       "get not found",
+			method:     http.MethodGet,
+			statusCode: http.StatusNotFound,
+			want:       false,
+		},
+		{
+			name:       "get too many requests",
+			method:     http.MethodGet,
+			statusCode: http.StatusTooManyRequests,
+			want:       true,
+		},
+		{
+			name:       "get bad gateway",
+			method:     http.MethodGet,
+			statusCode: http.StatusBadGateway,
+			want:       true,
+		},
+		{
+			name:       "put service unavailable",
+			method:     http.MethodPut,
+			statusCode: http.StatusServiceUnavailable,
+			want:       true,
+		},
+		{
+			name:       "delete gateway timeout",
+			method:     http.MethodDelete,
+			statusCode: http.StatusGatewayTimeout,
+			want:       true,
+		},
+		{
+			name:       "post bad gateway",
+			method:     http.MethodPost,
+			statusCode: http.StatusBadGateway,
+			want:       false,
+		},
+		{
+			name:       "post gateway timeout",
+			method:     http.MethodPost,
+			statusCode: http.StatusGatewayTimeout,
+			want:       false,
+		},
+		{
+			name:       "post service unavailable",
+			method:     http.MethodPost,
+			statusCode: http.StatusServiceUnavailable,
+			want:       false,
+		},
+		{
+			name:       "post service unavailable with retry after",
+			method:     http.MethodPost,
+			statusCode: http.StatusServiceUnavailable,
+			retryAfter: "1",
+			want:       true,
+		},
+		{
+			name:       "post too many requests with retry after",
+			method:     http.MethodPost,
+			statusCode: http.StatusTooManyRequests,
+			retryAfter: "1",
+			want:       true,
+		},
+		{
+			name:       "patch bad gateway with retry after",
+			method:     http.MethodPatch,
+			statusCode: http.StatusBadGateway,
+			retryAfter: "1",
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, "http://influxdb:8086/api/v2/authorizations", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.statusCode, Header: http.Header{}}
+				if len(tt.retryAfter) > 0 {
+					resp.Header.Set("Retry-After", tt.retryAfter)
+				}
+			}
+
+			if got := isRetryable(req, resp, tt.err); got != tt.want {
+				t.Errorf("isRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTripperRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		maxRetries   int
+		wantStatus   int
+		wantAttempts int
+	}{
+		{
+			name:         "recovered",
+			failures:     2,
+			maxRetries:   3,
+			wantStatus:   http.StatusOK,
+			wantAttempts: 3,
+		},
+		{
+			name:         "exhausted",
+			failures:     5,
+			maxRetries:   2,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantAttempts: 3,
+		},
+		{
+			name:         "disabled",
+			failures:     1,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				attempts++
+				if got := req.Header.Get("X-Tenant"); got != "team" {
+					t.Errorf("header X-Tenant = %q, want team", got)
+				}
+				if attempts <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			httpClient := &http.Client{
+				Transport: &roundTripper{
+					next:          http.DefaultTransport,
+					headers:       map[string]string{"X-Tenant": "team"},
+					maxRetries:    tt.maxRetries,
+					retryInterval: time.Millisecond,
+				},
+			}
+
+			resp, err := httpClient.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
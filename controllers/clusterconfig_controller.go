@@ -0,0 +1,115 @@
+/*
+Copyright 2022 kubetrail.io authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ClusterConfigReconciler reconciles a ClusterConfig object
+type ClusterConfigReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Clients is the influxdb client pool shared by reconcilers
+	Clients *ClientPool
+}
+
+//+kubebuilder:rbac:groups=influxdb.kubetrail.io,resources=clusterconfigs,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=influxdb.kubetrail.io,resources=clusterconfigs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// Reconcile checks connectivity to influxdb server described by the ClusterConfig
+// and publishes server version, health and org id in the status.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.10.0/pkg/reconcile
+func (r *ClusterConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+
+	object := &influxdbv1beta1.ClusterConfig{}
+	if err := r.Get(ctx, req.NamespacedName, object); err != nil {
+		if apimachineryerrors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Return and don't requeue
+			reqLogger.Info("object not found")
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		reqLogger.Error(err, "failed to get object")
+		return ctrl.Result{}, err
+	}
+
+	// cluster config does not own influxdb resources, hence there is nothing to finalize
+	if object.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.ReconcileStatus(ctx, object); err != nil {
+		if errors.Is(err, ObjectUpdated) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// requeue to maintain the state
+	return ctrl.Result{
+		Requeue:      true,
+		RequeueAfter: time.Minute,
+	}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(
+		context.Background(),
+		&influxdbv1beta1.ClusterConfig{},
+		secretRefIndexKey,
+		indexConfigSecretRefs,
+	); err != nil {
+		return err
+	}
+
+	// changes in credential and tls secrets are propagated to configs referencing them
+	mapFunc := mapSecretToDependents(r.Client, func() client.ObjectList {
+		return &influxdbv1beta1.ClusterConfigList{}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&influxdbv1beta1.ClusterConfig{}).
+		Watches(
+			&source.Kind{Type: &v1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(mapFunc),
+		).
+		Complete(r)
+}
@@ -0,0 +1,13 @@
+package controllers
+
+import (
+	"context"
+
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+)
+
+// ReconcileStatus checks token secret, server health and org referenced
+// by the cluster config and records the outcome in the status
+func (r *ClusterConfigReconciler) ReconcileStatus(ctx context.Context, object *influxdbv1beta1.ClusterConfig) error {
+	return updateConfigStatus(ctx, r.Client, r.Recorder, r.Clients, object, object.GetConfig(), &object.Status)
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// getConfig reads config referenced by an object in the namespace. ClusterConfig
+// is returned as a Config in its namespace after checking that the namespace
+// is allowed to reference it
+func getConfig(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+	ref influxdbv1beta1.ConfigReference,
+) (*influxdbv1beta1.Config, error) {
+	switch ref.Kind {
+	case influxdbv1beta1.ConfigKind, "":
+		config := &influxdbv1beta1.Config{}
+		if err := c.Get(ctx, types.NamespacedName{
+			Namespace: namespace,
+			Name:      ref.Name,
+		}, config); err != nil {
+			return nil, err
+		}
+		return config, nil
+	case influxdbv1beta1.ClusterConfigKind:
+		clusterConfig := &influxdbv1beta1.ClusterConfig{}
+		if err := c.Get(ctx, types.NamespacedName{
+			Name: ref.Name,
+		}, clusterConfig); err != nil {
+			return nil, err
+		}
+
+		allowed, err := isNamespaceAllowed(ctx, c, clusterConfig, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if !allowed {
+			return nil, fmt.Errorf("%w: namespace %s cannot reference cluster config %s",
+				ConfigNotAllowed, namespace, ref.Name)
+		}
+
+		return clusterConfig.GetConfig(), nil
+	default:
+		return nil, fmt.Errorf("invalid config kind %s", ref.Kind)
+	}
+}
+
+// isNamespaceAllowed checks if the namespace is in the allow-list of
+// the cluster config or matches its namespace selector
+func isNamespaceAllowed(
+	ctx context.Context,
+	c client.Client,
+	clusterConfig *influxdbv1beta1.ClusterConfig,
+	namespace string,
+) (bool, error) {
+	for _, allowedNamespace := range clusterConfig.Spec.AllowedNamespaces {
+		if allowedNamespace == namespace {
+			return true, nil
+		}
+	}
+
+	if clusterConfig.Spec.NamespaceSelector == nil {
+		return false, nil
+	}
+
+	selector, err := v12.LabelSelectorAsSelector(clusterConfig.Spec.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespace selector: %w", err)
+	}
+
+	ns := &v1.Namespace{}
+	if err := c.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return false, err
+	}
+
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// findConfigOrganization returns org referenced by the config. Org id takes
+// precedence over org name, which then only needs to match the name of the org
+func findConfigOrganization(
+	ctx context.Context,
+	orgApi api.OrganizationsAPI,
+	spec *influxdbv1beta1.ConfigSpec,
+) (*domain.Organization, error) {
+	if len(spec.OrgID) == 0 {
+		return orgApi.FindOrganizationByName(ctx, spec.OrgName)
+	}
+
+	organization, err := orgApi.FindOrganizationByID(ctx, spec.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if organization != nil && len(spec.OrgName) > 0 && organization.Name != spec.OrgName {
+		return nil, fmt.Errorf("%w: organization %s is named %s, not %s",
+			OrganizationNotFound, spec.OrgID, organization.Name, spec.OrgName)
+	}
+
+	return organization, nil
+}
+
+// getConfigOrgRef returns org id of the config or org name when no id is set
+func getConfigOrgRef(spec *influxdbv1beta1.ConfigSpec) string {
+	if len(spec.OrgID) > 0 {
+		return spec.OrgID
+	}
+
+	return spec.OrgName
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ReconcileStatus checks token secret, server health and org referenced
+// by the config and records the outcome in the status
+func (r *ConfigReconciler) ReconcileStatus(ctx context.Context, object *influxdbv1beta1.Config) error {
+	return updateConfigStatus(ctx, r.Client, r.Recorder, r.Clients, object, object, &object.Status)
+}
+
+// updateConfigStatus checks config and updates config status held by the object
+// emitting an event when readiness changes. Pooled client of a config failing
+// checks is dropped so that reconcilers start over with a new one
+func updateConfigStatus(
+	ctx context.Context,
+	c client.Client,
+	recorder record.EventRecorder,
+	clients *ClientPool,
+	object client.Object,
+	config *influxdbv1beta1.Config,
+	current *influxdbv1beta1.ConfigStatus,
+) error {
+	reqLogger := log.FromContext(ctx)
+
+	status := current.DeepCopy()
+	checkConfig(ctx, c, config, status)
+
+	if !meta.IsStatusConditionTrue(status.Conditions, conditionTypeReady) {
+		clients.Invalidate(config.UID)
+	}
+
+	if reflect.DeepEqual(current, status) {
+		return nil
+	}
+
+	readyBefore := meta.IsStatusConditionTrue(current.Conditions, conditionTypeReady)
+	readyAfter := meta.IsStatusConditionTrue(status.Conditions, conditionTypeReady)
+	switchedEndpoint := len(current.ActiveAddr) > 0 && len(status.ActiveAddr) > 0 &&
+		current.ActiveAddr != status.ActiveAddr
+	previousAddr := current.ActiveAddr
+
+	*current = *status
+	if err := c.Status().Update(ctx, object); err != nil {
+		reqLogger.Error(err, "failed to update object status")
+		return err
+	}
+	reqLogger.Info("updated object status")
+
+	if switchedEndpoint {
+		recorder.Eventf(object, v1.EventTypeWarning, reasonSwitchedEndpoint,
+			"switched from endpoint %s to %s", previousAddr, status.ActiveAddr)
+	}
+
+	if readyBefore != readyAfter {
+		if readyAfter {
+			recorder.Event(object, v1.EventTypeNormal, status.Reason, status.Message)
+		} else {
+			recorder.Event(object, v1.EventTypeWarning, status.Reason, status.Message)
+		}
+	}
+
+	return nil
+}
+
+// checkConfig populates status with the outcome of connectivity checks
+func checkConfig(
+	ctx context.Context,
+	c client.Client,
+	object *influxdbv1beta1.Config,
+	status *influxdbv1beta1.ConfigStatus,
+) {
+	reqLogger := log.FromContext(ctx)
+
+	// credentials are checked separately to tell missing secrets apart
+	if _, err := getCredentials(ctx, c, object); err != nil {
+		reqLogger.Error(err, "failed to read influxdb credentials")
+		if apimachineryerrors.IsNotFound(err) {
+			setConfigNotReady(status, reasonTokenSecretNotFound, err.Error())
+		} else {
+			setConfigNotReady(status, reasonTokenNotFound, err.Error())
+		}
+		return
+	}
+
+	newClient, err := newInfluxdbClient(ctx, c, object, nil)
+	if err != nil {
+		reqLogger.Error(err, "failed to create influxdb client")
+		switch {
+		case errors.Is(err, NoHealthyEndpoint):
+			status.Health = ""
+			status.ActiveAddr = ""
+			setConfigNotReady(status, reasonServerUnreachable, err.Error())
+		case isHttpStatusCode(err, 401), isHttpStatusCode(err, 403):
+			setConfigNotReady(status, reasonUnauthorized, err.Error())
+		default:
+			setConfigNotReady(status, reasonInvalidClientConfig, fmt.Sprintf("invalid client settings: %s", err))
+		}
+		return
+	}
+	// always close client at the end
+	defer newClient.Close()
+
+	status.ActiveAddr = newClient.ServerURL()
+
+	if ok, err := newClient.Ping(ctx); err != nil || !ok {
+		if err == nil {
+			err = fmt.Errorf("unexpected ping response")
+		}
+		reqLogger.Error(err, "failed to ping influxdb")
+		status.Health = ""
+		setConfigNotReady(status, reasonServerUnreachable,
+			fmt.Sprintf("failed to reach influxdb at %s: %s", status.ActiveAddr, err))
+		return
+	}
+
+	health, err := newClient.Health(ctx)
+	if err != nil {
+		reqLogger.Error(err, "failed to get influxdb health")
+		status.Health = ""
+		setConfigNotReady(status, reasonServerUnreachable,
+			fmt.Sprintf("failed to get health of influxdb at %s: %s", status.ActiveAddr, err))
+		return
+	}
+
+	status.Health = string(health.Status)
+	if health.Version != nil {
+		status.Version = *health.Version
+	}
+
+	if health.Status != domain.HealthCheckStatusPass {
+		message := "influxdb is unhealthy"
+		if health.Message != nil {
+			message = fmt.Sprintf("%s: %s", message, *health.Message)
+		}
+		setConfigNotReady(status, reasonServerUnhealthy, message)
+		return
+	}
+
+	organization, err := findConfigOrganization(ctx, newClient.OrganizationsAPI(), &object.Spec)
+	if err != nil {
+		reqLogger.Error(err, "failed to find organization")
+		switch {
+		case errors.Is(err, OrganizationNotFound):
+			setConfigNotReady(status, reasonOrganizationNotFound, err.Error())
+		case isHttpStatusCode(err, 401), isHttpStatusCode(err, 403):
+			setConfigNotReady(status, reasonUnauthorized,
+				fmt.Sprintf("token is not authorized: %s", err))
+		case isHttpStatusCode(err, 404):
+			setConfigNotReady(status, reasonOrganizationNotFound,
+				fmt.Sprintf("organization %s not found", getConfigOrgRef(&object.Spec)))
+		default:
+			setConfigNotReady(status, reasonServerUnreachable,
+				fmt.Sprintf("failed to find organization: %s", err))
+		}
+		return
+	}
+
+	if organization == nil || organization.Id == nil || len(*organization.Id) == 0 {
+		setConfigNotReady(status, reasonOrganizationNotFound, "received nil org pointer or invalid id")
+		return
+	}
+
+	status.OrgID = *organization.Id
+	status.Phase = phaseReady
+	status.Reason = reasonConnected
+	status.Message = fmt.Sprintf("connected to influxdb %s", status.Version)
+	meta.SetStatusCondition(&status.Conditions, v12.Condition{
+		Type:    conditionTypeReady,
+		Status:  v12.ConditionTrue,
+		Reason:  reasonConnected,
+		Message: status.Message,
+	})
+}
+
+// setConfigNotReady records failed check in the status
+func setConfigNotReady(status *influxdbv1beta1.ConfigStatus, reason, message string) {
+	status.Phase = phaseError
+	status.Reason = reason
+	status.Message = message
+	meta.SetStatusCondition(&status.Conditions, v12.Condition{
+		Type:    conditionTypeReady,
+		Status:  v12.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	})
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	influxdb "github.com/influxdata/influxdb-client-go/v2"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+)
+
+func TestFindConfigOrganization(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeInfluxdb(t)
+	orgId := server.CreateOrg("team")
+	server.CreateOrg("other")
+
+	newClient := influxdb.NewClient(server.URL, "admin-token")
+	defer newClient.Close()
+
+	tests := []struct {
+		name         string
+		spec         influxdbv1beta1.ConfigSpec
+		wantNotFound bool
+		wantErr      bool
+	}{
+		{
+			name: "org name",
+			spec: influxdbv1beta1.ConfigSpec{OrgName: "team"},
+		},
+		{
+			name: "org id",
+			spec: influxdbv1beta1.ConfigSpec{OrgID: orgId},
+		},
+		{
+			name: "org id and matching name",
+			spec: influxdbv1beta1.ConfigSpec{OrgID: orgId, OrgName: "team"},
+		},
+		{
+			name:         "org id and other name",
+			spec:         influxdbv1beta1.ConfigSpec{OrgID: orgId, OrgName: "other"},
+			wantNotFound: true,
+			wantErr:      true,
+		},
+		{
+			name:    "unknown org id",
+			spec:    influxdbv1beta1.ConfigSpec{OrgID: "unknown", OrgName: "team"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			organization, err := findConfigOrganization(ctx, newClient.OrganizationsAPI(), &tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findConfigOrganization() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, OrganizationNotFound) != tt.wantNotFound {
+				t.Errorf("findConfigOrganization() error = %v, want organization not found %v", err, tt.wantNotFound)
+			}
+			if err != nil {
+				return
+			}
+
+			if organization == nil || organization.Id == nil || *organization.Id != orgId {
+				t.Errorf("findConfigOrganization() = %v, want org %s", organization, orgId)
+			}
+		})
+	}
+}
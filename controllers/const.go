@@ -7,19 +7,66 @@ const (
 	reasonFinalizerAdded          = "finalizerAdded"
 	reasonCreatedBucket           = "createdBucket"
 	reasonDeletedBucket           = "deletedBucket"
+	reasonUpdatedBucket           = "updatedBucket"
 	reasonCreatedOrganization     = "createdOrganization"
 	reasonDeletedOrganization     = "deletedOrganization"
+	reasonUpdatedOrganization     = "updatedOrganization"
 	reasonCreatedToken            = "createdToken"
 	reasonDeletedToken            = "deletedToken"
+	reasonRetainedBucket          = "retainedBucket"
+	reasonRetainedOrganization    = "retainedOrganization"
+	reasonRetainedToken           = "retainedToken"
+	reasonRotatedToken            = "rotatedToken"
+	reasonReplacedToken           = "replacedToken"
+	reasonWaitingForBucket        = "waitingForBucket"
+	reasonWaitingForUser          = "waitingForUser"
+	reasonWaitingForOrganization  = "waitingForOrganization"
+	reasonPasswordNotFound        = "passwordNotFound"
+	reasonCreatedUser             = "createdUser"
+	reasonDeletedUser             = "deletedUser"
+	reasonUpdatedUser             = "updatedUser"
+	reasonRetainedUser            = "retainedUser"
+	reasonAdoptedUser             = "adoptedUser"
+	reasonConflictingUser         = "conflictingUser"
+	reasonGeneratedConfig         = "generatedConfig"
+	reasonRemovedGeneratedConfig  = "removedGeneratedConfig"
+	reasonConflictingConfig       = "conflictingConfig"
+	reasonAdoptedBucket           = "adoptedBucket"
+	reasonAdoptedOrganization     = "adoptedOrganization"
+	reasonConflictingBucket       = "conflictingBucket"
+	reasonConflictingOrganization = "conflictingOrganization"
+	reasonConnected               = "connected"
+	reasonTokenSecretNotFound     = "tokenSecretNotFound"
+	reasonTokenNotFound           = "tokenNotFound"
+	reasonServerUnreachable       = "serverUnreachable"
+	reasonServerUnhealthy         = "serverUnhealthy"
+	reasonUnauthorized            = "unauthorized"
+	reasonOrganizationNotFound    = "organizationNotFound"
+	reasonInvalidClientConfig     = "invalidClientConfig"
+	reasonSwitchedEndpoint        = "switchedEndpoint"
 	phasePending                  = "pending"
 	phaseReady                    = "ready"
 	phaseTerminating              = "terminating"
+	phaseConflict                 = "conflict"
+	phaseError                    = "error"
 	conditionTypeObject           = "object"
 	conditionTypeInfluxdb         = "influxdb"
+	conditionTypeReady            = "Ready"
 )
 
 const (
 	configInfluxdb = "default"
 	keyToken       = "token"
 	keyTokenId     = "tokenId"
+	keyCABundle    = "ca.crt"
+	keyPassword    = "password"
+)
+
+const (
+	bucketPageSize = 100
+)
+
+const (
+	managedBy              = "influxdb-operator"
+	labelPropertyManagedBy = "managedBy"
 )
@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+)
+
+// getDeletionPolicy returns deletion policy defined on the object falling back
+// to operator default and finally to deleting influxdb resources
+func getDeletionPolicy(policy, defaultPolicy string) string {
+	if len(policy) > 0 {
+		return policy
+	}
+
+	if len(defaultPolicy) > 0 {
+		return defaultPolicy
+	}
+
+	return influxdbv1beta1.DeletionPolicyDelete
+}
@@ -1,11 +1,20 @@
 package controllers
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/http"
+)
 
 type Error string
 
 const (
-	ObjectUpdated Error = "object-updated"
+	ObjectUpdated        Error = "object-updated"
+	ConfigNotAllowed     Error = "config-not-allowed"
+	NoHealthyEndpoint    Error = "no-healthy-endpoint"
+	UserNotFound         Error = "user-not-found"
+	OrganizationNotFound Error = "organization-not-found"
+	ResourceNotOwned     Error = "resource-not-owned"
 )
 
 func (e Error) Error() string {
@@ -21,3 +30,15 @@ func (e Error) Is(target error) bool {
 	}
 	return false
 }
+
+// isHttpStatusCode checks if err is an influxdb http error with matching status code
+func isHttpStatusCode(err error, statusCode int) bool {
+	httpErr := &http.Error{
+		StatusCode: 0,
+		Code:       "",
+		Message:    "",
+		Err:        nil,
+		RetryAfter: 0,
+	}
+	return errors.As(err, &httpErr) && httpErr.StatusCode == statusCode
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// maxReconcilePasses bounds passes of reconcileObject
+const maxReconcilePasses = 20
+
+func init() {
+	// fake client decodes lists via the client-go scheme
+	utilruntime.Must(influxdbv1beta1.AddToScheme(scheme.Scheme))
+}
+
+// indexedClient filters lists by field selectors using index functions of
+// the reconcilers, which the fake client does not support
+type indexedClient struct {
+	client.Client
+	indexes map[string]client.IndexerFunc
+}
+
+// newFakeClient returns a fake client holding objects with field indexes
+// registered by reconcilers
+func newFakeClient(objects ...client.Object) *indexedClient {
+	return &indexedClient{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(objects...).
+			Build(),
+		indexes: map[string]client.IndexerFunc{
+			configRefIndexKey: indexConfigRef,
+			secretRefIndexKey: func(obj client.Object) []string {
+				return append(indexConfigSecretRefs(obj), indexUserSecretRefs(obj)...)
+			},
+		},
+	}
+}
+
+// List lists objects filtering them by field selector, if any
+func (c *indexedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector == nil {
+		return nil
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	filtered := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		object, ok := item.(client.Object)
+		if !ok {
+			continue
+		}
+
+		matches := true
+		for _, requirement := range listOpts.FieldSelector.Requirements() {
+			index, ok := c.indexes[requirement.Field]
+			if !ok {
+				return fmt.Errorf("index with name field:%s does not exist", requirement.Field)
+			}
+			if !hasIndexValue(index(object), requirement.Value) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			filtered = append(filtered, item)
+		}
+	}
+
+	return meta.SetList(list, filtered)
+}
+
+// hasIndexValue checks if index values of an object contain the value
+func hasIndexValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reconcileObject reconciles the object until the reconciler requeues it after
+// a period, fails or the object is gone. Passes ending with an update of the
+// object are followed by another pass, as the watch would trigger one
+func reconcileObject(t *testing.T, r reconcile.Reconciler, c client.Client, object client.Object) error {
+	t.Helper()
+
+	key := client.ObjectKeyFromObject(object)
+	for i := 0; i < maxReconcilePasses; i++ {
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+		if err != nil {
+			return err
+		}
+
+		if result.RequeueAfter > 0 || getObject(t, c, object) == nil {
+			return nil
+		}
+	}
+
+	t.Fatalf("object %s not reconciled after %d passes", key, maxReconcilePasses)
+	return nil
+}
+
+// getObject returns current state of the object or nil if it does not exist.
+// Object is read into a new instance since decoding into the given one would
+// keep fields that are no longer set
+func getObject(t *testing.T, c client.Client, object client.Object) client.Object {
+	t.Helper()
+
+	current := reflect.New(reflect.TypeOf(object).Elem()).Interface().(client.Object)
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(object), current); err != nil {
+		if apimachineryerrors.IsNotFound(err) {
+			return nil
+		}
+		t.Fatalf("failed to get object: %v", err)
+	}
+
+	return current
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// field indexes allow looking up objects affected by a change in
+// the objects they reference
+const (
+	// configRefIndexKey indexes organizations, buckets, tokens and users by referenced config
+	configRefIndexKey = ".spec.configRef"
+	// secretRefIndexKey indexes configs, cluster configs and users by referenced secrets
+	secretRefIndexKey = ".spec.secretRefs"
+)
+
+// getConfigRefIndexValue returns index value of a config reference
+func getConfigRefIndexValue(ref influxdbv1beta1.ConfigReference) string {
+	kind := ref.Kind
+	if len(kind) == 0 {
+		kind = influxdbv1beta1.ConfigKind
+	}
+
+	return fmt.Sprintf("%s/%s", kind, ref.Name)
+}
+
+// getSecretRefIndexValue returns index value of a secret reference
+func getSecretRefIndexValue(namespace, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+// splitSecretRefIndexValue returns namespace and name of a secret reference index value
+func splitSecretRefIndexValue(value string) (string, string, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid secret reference %s", value)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// getConfigSecretRefs returns index values of secrets referenced by the config
+func getConfigSecretRefs(config *influxdbv1beta1.Config) []string {
+	var refs []string
+
+	if basicAuth := config.Spec.BasicAuth; basicAuth != nil {
+		namespace := basicAuth.SecretNamespace
+		if len(namespace) == 0 {
+			namespace = config.Namespace
+		}
+		refs = append(refs, getSecretRefIndexValue(namespace, basicAuth.SecretName))
+	} else if len(config.Spec.TokenSecretName) > 0 {
+		refs = append(refs, getSecretRefIndexValue(config.Spec.TokenSecretNamespace, config.Spec.TokenSecretName))
+	}
+
+	if tls := config.Spec.TLS; tls != nil {
+		if tls.CA != nil && len(tls.CA.SecretName) > 0 {
+			refs = append(refs, getSecretRefIndexValue(config.Namespace, tls.CA.SecretName))
+		}
+		if len(tls.ClientCertSecretName) > 0 {
+			refs = append(refs, getSecretRefIndexValue(config.Namespace, tls.ClientCertSecretName))
+		}
+	}
+
+	return refs
+}
+
+// indexConfigSecretRefs is the index function of configs and cluster configs by referenced secrets
+func indexConfigSecretRefs(obj client.Object) []string {
+	switch object := obj.(type) {
+	case *influxdbv1beta1.Config:
+		return getConfigSecretRefs(object)
+	case *influxdbv1beta1.ClusterConfig:
+		return getConfigSecretRefs(object.GetConfig())
+	default:
+		return nil
+	}
+}
+
+// indexUserSecretRefs is the index function of users by referenced password secret
+func indexUserSecretRefs(obj client.Object) []string {
+	object, ok := obj.(*influxdbv1beta1.User)
+	if !ok || object.Spec.PasswordSecret == nil {
+		return nil
+	}
+
+	return []string{getSecretRefIndexValue(object.Namespace, object.Spec.PasswordSecret.Name)}
+}
+
+// indexConfigRef is the index function of organizations, buckets, tokens and users by referenced config
+func indexConfigRef(obj client.Object) []string {
+	switch object := obj.(type) {
+	case *influxdbv1beta1.Organization:
+		return []string{getConfigRefIndexValue(object.GetConfigRef())}
+	case *influxdbv1beta1.Bucket:
+		return []string{getConfigRefIndexValue(object.GetConfigRef())}
+	case *influxdbv1beta1.Token:
+		return []string{getConfigRefIndexValue(object.GetConfigRef())}
+	case *influxdbv1beta1.User:
+		return []string{getConfigRefIndexValue(object.GetConfigRef())}
+	default:
+		return nil
+	}
+}
+
+// mapConfigToDependents returns a map function enqueuing objects of the list
+// type that reference a changed Config or ClusterConfig
+func mapConfigToDependents(c client.Client, newList func() client.ObjectList) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		var opts []client.ListOption
+		switch obj.(type) {
+		case *influxdbv1beta1.Config:
+			opts = append(opts,
+				client.InNamespace(obj.GetNamespace()),
+				client.MatchingFields{
+					configRefIndexKey: getConfigRefIndexValue(influxdbv1beta1.ConfigReference{
+						Kind: influxdbv1beta1.ConfigKind,
+						Name: obj.GetName(),
+					}),
+				},
+			)
+		case *influxdbv1beta1.ClusterConfig:
+			opts = append(opts,
+				client.MatchingFields{
+					configRefIndexKey: getConfigRefIndexValue(influxdbv1beta1.ConfigReference{
+						Kind: influxdbv1beta1.ClusterConfigKind,
+						Name: obj.GetName(),
+					}),
+				},
+			)
+		default:
+			return nil
+		}
+
+		return listRequests(c, newList(), opts...)
+	}
+}
+
+// mapSecretToDependents returns a map function enqueuing objects of the
+// list type that reference a changed secret
+func mapSecretToDependents(c client.Client, newList func() client.ObjectList) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		return listRequests(c, newList(),
+			client.MatchingFields{
+				secretRefIndexKey: getSecretRefIndexValue(obj.GetNamespace(), obj.GetName()),
+			},
+		)
+	}
+}
+
+// mapSecretToConfigDependents returns a map function enqueuing objects of the
+// list type that reference a Config or ClusterConfig using a changed secret.
+// Config status does not change along with secret data, hence dependents are
+// looked up via configs indexed by the config reconcilers
+func mapSecretToConfigDependents(c client.Client, newList func() client.ObjectList) handler.MapFunc {
+	mapConfig := mapConfigToDependents(c, newList)
+	return func(obj client.Object) []reconcile.Request {
+		secretRef := client.MatchingFields{
+			secretRefIndexKey: getSecretRefIndexValue(obj.GetNamespace(), obj.GetName()),
+		}
+
+		var requests []reconcile.Request
+
+		configs := &influxdbv1beta1.ConfigList{}
+		if err := c.List(context.Background(), configs, secretRef); err == nil {
+			for i := range configs.Items {
+				requests = append(requests, mapConfig(&configs.Items[i])...)
+			}
+		}
+
+		clusterConfigs := &influxdbv1beta1.ClusterConfigList{}
+		if err := c.List(context.Background(), clusterConfigs, secretRef); err == nil {
+			for i := range clusterConfigs.Items {
+				requests = append(requests, mapConfig(&clusterConfigs.Items[i])...)
+			}
+		}
+
+		return requests
+	}
+}
+
+// listRequests lists objects and returns reconcile requests for them
+func listRequests(c client.Client, list client.ObjectList, opts ...client.ListOption) []reconcile.Request {
+	if err := c.List(context.Background(), list, opts...); err != nil {
+		return nil
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(items))
+	for _, item := range items {
+		object, ok := item.(client.Object)
+		if !ok {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: object.GetNamespace(),
+				Name:      object.GetName(),
+			},
+		})
+	}
+
+	return requests
+}
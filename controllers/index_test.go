@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestMapSecretToConfigDependents(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: v12.ObjectMeta{Name: "admin-token", Namespace: "influxdb2-system"},
+	}
+
+	c := newFakeClient(
+		&influxdbv1beta1.Config{
+			ObjectMeta: v12.ObjectMeta{Name: "default", Namespace: "team"},
+			Spec: influxdbv1beta1.ConfigSpec{
+				Addr:                 "http://influxdb:8086",
+				OrgName:              "team",
+				TokenSecretName:      secret.Name,
+				TokenSecretNamespace: secret.Namespace,
+			},
+		},
+		&influxdbv1beta1.Config{
+			ObjectMeta: v12.ObjectMeta{Name: "other", Namespace: "team"},
+			Spec: influxdbv1beta1.ConfigSpec{
+				Addr:                 "http://influxdb:8086",
+				OrgName:              "team",
+				TokenSecretName:      "other-token",
+				TokenSecretNamespace: "team",
+			},
+		},
+		&influxdbv1beta1.ClusterConfig{
+			ObjectMeta: v12.ObjectMeta{Name: "shared"},
+			Spec: influxdbv1beta1.ClusterConfigSpec{
+				Namespace: secret.Namespace,
+				ConfigSpec: influxdbv1beta1.ConfigSpec{
+					Addr:                 "http://influxdb:8086",
+					OrgName:              "shared",
+					TokenSecretName:      secret.Name,
+					TokenSecretNamespace: secret.Namespace,
+				},
+			},
+		},
+		&influxdbv1beta1.Bucket{
+			ObjectMeta: v12.ObjectMeta{Name: "metrics", Namespace: "team"},
+		},
+		&influxdbv1beta1.Bucket{
+			ObjectMeta: v12.ObjectMeta{Name: "logs", Namespace: "team"},
+			Spec: influxdbv1beta1.BucketSpec{
+				ConfigRef: &influxdbv1beta1.ConfigReference{
+					Kind: influxdbv1beta1.ClusterConfigKind,
+					Name: "shared",
+				},
+			},
+		},
+		&influxdbv1beta1.Bucket{
+			ObjectMeta: v12.ObjectMeta{Name: "traces", Namespace: "team"},
+			Spec:       influxdbv1beta1.BucketSpec{ConfigName: "other"},
+		},
+		&influxdbv1beta1.Bucket{
+			ObjectMeta: v12.ObjectMeta{Name: "metrics", Namespace: "elsewhere"},
+		},
+	)
+
+	mapFunc := mapSecretToConfigDependents(c, func() client.ObjectList {
+		return &influxdbv1beta1.BucketList{}
+	})
+
+	var got []string
+	for _, request := range mapFunc(secret) {
+		got = append(got, request.String())
+	}
+	sort.Strings(got)
+
+	want := []string{
+		reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "team", Name: "logs"}}.String(),
+		reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "team", Name: "metrics"}}.String(),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("secret update enqueued %v, want %v", got, want)
+	}
+}
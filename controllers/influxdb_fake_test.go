@@ -0,0 +1,837 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// fakeOwnerRole is role of org owners, which share the members response type
+	fakeOwnerRole = domain.ResourceMemberRole(domain.ResourceOwnerRoleOwner)
+	// fakeAdminId is id of the user that clients of the fake server authenticate as
+	fakeAdminId = "admin"
+)
+
+// fakeInfluxdb emulates the subset of influxdb v2 api used by the reconcilers
+// keeping orgs, buckets, authorizations, users and labels in memory
+type fakeInfluxdb struct {
+	*httptest.Server
+
+	mu             sync.Mutex
+	lastId         int
+	orgs           map[string]*domain.Organization
+	members        map[string]map[string]domain.ResourceMemberRole
+	buckets        map[string]*domain.Bucket
+	bucketLabels   map[string][]string
+	authorizations map[string]*domain.Authorization
+	users          map[string]*domain.User
+	passwords      map[string]string
+	labels         map[string]*domain.Label
+
+	// health reported by the health endpoint
+	health domain.HealthCheckStatus
+	// failures, if set, returns status code responding to api requests
+	// instead of serving them
+	failures func(req *http.Request) int
+}
+
+// newFakeInfluxdb starts fake influxdb server that is closed when the test ends
+func newFakeInfluxdb(t *testing.T) *fakeInfluxdb {
+	f := &fakeInfluxdb{
+		orgs:           make(map[string]*domain.Organization),
+		members:        make(map[string]map[string]domain.ResourceMemberRole),
+		buckets:        make(map[string]*domain.Bucket),
+		bucketLabels:   make(map[string][]string),
+		authorizations: make(map[string]*domain.Authorization),
+		users:          make(map[string]*domain.User),
+		passwords:      make(map[string]string),
+		labels:         make(map[string]*domain.Label),
+		health:         domain.HealthCheckStatusPass,
+	}
+	f.users[fakeAdminId] = &domain.User{Id: stringPtr(fakeAdminId), Name: "admin"}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+// Config returns config in the namespace connecting to the fake server
+// with the token of TokenSecret
+func (f *fakeInfluxdb) Config(namespace, name, orgName string) *influxdbv1beta1.Config {
+	return &influxdbv1beta1.Config{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			UID:        types.UID(namespace + "-" + name),
+			Generation: 1,
+		},
+		Spec: influxdbv1beta1.ConfigSpec{
+			Addr:                 f.URL,
+			OrgName:              orgName,
+			TokenSecretName:      "influxdb-token",
+			TokenSecretNamespace: namespace,
+		},
+	}
+}
+
+// TokenSecret returns token secret referenced by configs of the namespace
+func (f *fakeInfluxdb) TokenSecret(namespace string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "influxdb-token", Namespace: namespace},
+		Data:       map[string][]byte{keyToken: []byte("admin-token")},
+	}
+}
+
+// newId returns next influxdb like id
+func (f *fakeInfluxdb) newId() string {
+	f.lastId++
+	return fmt.Sprintf("%016x", f.lastId)
+}
+
+// CreateOrg adds an org returning its id
+func (f *fakeInfluxdb) CreateOrg(name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.createOrg(name, nil)
+}
+
+func (f *fakeInfluxdb) createOrg(name string, description *string) string {
+	id := f.newId()
+	f.orgs[id] = &domain.Organization{Id: &id, Name: name, Description: description}
+	f.members[id] = make(map[string]domain.ResourceMemberRole)
+	return id
+}
+
+// CreateBucket adds a bucket to the org returning its id
+func (f *fakeInfluxdb) CreateBucket(orgId, name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.newId()
+	f.buckets[id] = &domain.Bucket{
+		Id:             &id,
+		Name:           name,
+		OrgID:          &orgId,
+		RetentionRules: domain.RetentionRules{},
+	}
+	return id
+}
+
+// CreateUser adds a user returning its id
+func (f *fakeInfluxdb) CreateUser(name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.newId()
+	f.users[id] = &domain.User{Id: &id, Name: name}
+	return id
+}
+
+// AddMember adds the user to members of the org
+func (f *fakeInfluxdb) AddMember(orgId, userId string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.members[orgId][userId] = domain.ResourceMemberRoleMember
+}
+
+// Org returns copy of the org, if any
+func (f *fakeInfluxdb) Org(id string) *domain.Organization {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	org, ok := f.orgs[id]
+	if !ok {
+		return nil
+	}
+	result := *org
+	return &result
+}
+
+// Bucket returns copy of the bucket, if any
+func (f *fakeInfluxdb) Bucket(id string) *domain.Bucket {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, ok := f.buckets[id]
+	if !ok {
+		return nil
+	}
+	result := *bucket
+	return &result
+}
+
+// Authorization returns copy of the authorization, if any
+func (f *fakeInfluxdb) Authorization(id string) *domain.Authorization {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	authorization, ok := f.authorizations[id]
+	if !ok {
+		return nil
+	}
+	result := *authorization
+	return &result
+}
+
+// User returns copy of the user, if any
+func (f *fakeInfluxdb) User(id string) *domain.User {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[id]
+	if !ok {
+		return nil
+	}
+	result := *user
+	return &result
+}
+
+// Members returns sorted ids of users having the role in the org
+func (f *fakeInfluxdb) Members(orgId string, role domain.ResourceMemberRole) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var ids []string
+	for userId, userRole := range f.members[orgId] {
+		if userRole == role {
+			ids = append(ids, userId)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// LabelNames returns sorted names of labels in the org
+func (f *fakeInfluxdb) LabelNames(orgId string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var names []string
+	for _, label := range f.labels {
+		if *label.OrgID == orgId {
+			names = append(names, *label.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AuthorizationCount returns number of authorizations in the org
+func (f *fakeInfluxdb) AuthorizationCount(orgId string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	count := 0
+	for _, authorization := range f.authorizations {
+		if *authorization.OrgID == orgId {
+			count++
+		}
+	}
+	return count
+}
+
+// SetHealth sets status reported by health endpoint
+func (f *fakeInfluxdb) SetHealth(status domain.HealthCheckStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.health = status
+}
+
+// SetFailures sets function deciding status code of failing api requests
+func (f *fakeInfluxdb) SetFailures(failures func(req *http.Request) int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = failures
+}
+
+func (f *fakeInfluxdb) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch req.URL.Path {
+	case "/health":
+		status := http.StatusOK
+		if f.health != domain.HealthCheckStatusPass {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, domain.HealthCheck{Name: "influxdb", Status: f.health, Version: stringPtr("2.1.1")})
+		return
+	case "/ping":
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if f.failures != nil {
+		if status := f.failures(req); status != 0 {
+			writeError(w, status, "injected failure")
+			return
+		}
+	}
+
+	path := strings.Split(strings.TrimPrefix(req.URL.Path, "/api/v2/"), "/")
+	switch path[0] {
+	case "signin", "signout":
+		w.WriteHeader(http.StatusNoContent)
+	case "me":
+		writeJSON(w, http.StatusOK, userResponse(f.users[fakeAdminId]))
+	case "orgs":
+		f.serveOrgs(w, req, path[1:])
+	case "buckets":
+		f.serveBuckets(w, req, path[1:])
+	case "authorizations":
+		f.serveAuthorizations(w, req, path[1:])
+	case "users":
+		f.serveUsers(w, req, path[1:])
+	case "labels":
+		f.serveLabels(w, req, path[1:])
+	default:
+		writeError(w, http.StatusNotFound, "path not found")
+	}
+}
+
+func (f *fakeInfluxdb) serveOrgs(w http.ResponseWriter, req *http.Request, path []string) {
+	query := req.URL.Query()
+
+	if len(path) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			orgs := []domain.Organization{}
+			for _, org := range f.orgs {
+				if name := query.Get("org"); len(name) > 0 && org.Name != name {
+					continue
+				}
+				if id := query.Get("orgID"); len(id) > 0 && *org.Id != id {
+					continue
+				}
+				orgs = append(orgs, *org)
+			}
+			if len(query.Get("org")) > 0 && len(orgs) == 0 {
+				writeError(w, http.StatusNotFound, fmt.Sprintf("organization name %q not found", query.Get("org")))
+				return
+			}
+			writeJSON(w, http.StatusOK, domain.Organizations{Orgs: &orgs})
+		case http.MethodPost:
+			body := domain.PostOrganizationRequest{}
+			if !readJSON(w, req, &body) {
+				return
+			}
+			for _, org := range f.orgs {
+				if org.Name == body.Name {
+					writeError(w, http.StatusUnprocessableEntity, "organization name is not unique")
+					return
+				}
+			}
+			writeJSON(w, http.StatusCreated, f.orgs[f.createOrg(body.Name, body.Description)])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	org, ok := f.orgs[path[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "organization not found")
+		return
+	}
+
+	if len(path) == 1 {
+		switch req.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, org)
+		case http.MethodPatch:
+			body := domain.PatchOrganizationRequest{}
+			if !readJSON(w, req, &body) {
+				return
+			}
+			if body.Name != nil {
+				org.Name = *body.Name
+			}
+			if body.Description != nil {
+				org.Description = body.Description
+			}
+			writeJSON(w, http.StatusOK, org)
+		case http.MethodDelete:
+			delete(f.orgs, *org.Id)
+			delete(f.members, *org.Id)
+			for id, bucket := range f.buckets {
+				if *bucket.OrgID == *org.Id {
+					delete(f.buckets, id)
+				}
+			}
+			for id, authorization := range f.authorizations {
+				if *authorization.OrgID == *org.Id {
+					delete(f.authorizations, id)
+				}
+			}
+			for id, label := range f.labels {
+				if *label.OrgID == *org.Id {
+					delete(f.labels, id)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	role := domain.ResourceMemberRoleMember
+	switch path[1] {
+	case "members":
+	case "owners":
+		role = fakeOwnerRole
+	default:
+		writeError(w, http.StatusNotFound, "path not found")
+		return
+	}
+
+	members := f.members[*org.Id]
+	switch {
+	case len(path) == 2 && req.Method == http.MethodGet:
+		users := []domain.ResourceMember{}
+		for userId, userRole := range members {
+			if userRole != role {
+				continue
+			}
+			userRole := userRole
+			users = append(users, domain.ResourceMember{
+				UserResponse: domain.UserResponse{Id: f.users[userId].Id, Name: f.users[userId].Name},
+				Role:         &userRole,
+			})
+		}
+		writeJSON(w, http.StatusOK, domain.ResourceMembers{Users: &users})
+	case len(path) == 2 && req.Method == http.MethodPost:
+		body := domain.AddResourceMemberRequestBody{}
+		if !readJSON(w, req, &body) {
+			return
+		}
+		user, ok := f.users[body.Id]
+		if !ok {
+			writeError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		members[body.Id] = role
+		writeJSON(w, http.StatusCreated, domain.ResourceMember{
+			UserResponse: domain.UserResponse{Id: user.Id, Name: user.Name},
+			Role:         &role,
+		})
+	case len(path) == 3 && req.Method == http.MethodDelete:
+		if members[path[2]] == role {
+			delete(members, path[2])
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (f *fakeInfluxdb) serveBuckets(w http.ResponseWriter, req *http.Request, path []string) {
+	query := req.URL.Query()
+
+	if len(path) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			buckets := []domain.Bucket{}
+			for _, bucket := range f.buckets {
+				if name := query.Get("name"); len(name) > 0 && bucket.Name != name {
+					continue
+				}
+				if orgId := query.Get("orgID"); len(orgId) > 0 && *bucket.OrgID != orgId {
+					continue
+				}
+				buckets = append(buckets, *bucket)
+			}
+			sort.Slice(buckets, func(i, j int) bool { return *buckets[i].Id < *buckets[j].Id })
+			writeJSON(w, http.StatusOK, domain.Buckets{Buckets: &buckets})
+		case http.MethodPost:
+			body := domain.PostBucketRequest{}
+			if !readJSON(w, req, &body) {
+				return
+			}
+			if _, ok := f.orgs[body.OrgID]; !ok {
+				writeError(w, http.StatusNotFound, "organization not found")
+				return
+			}
+			for _, bucket := range f.buckets {
+				if *bucket.OrgID == body.OrgID && bucket.Name == body.Name {
+					writeError(w, http.StatusUnprocessableEntity, "bucket with name already exists")
+					return
+				}
+			}
+			id := f.newId()
+			f.buckets[id] = &domain.Bucket{
+				Id:             &id,
+				Name:           body.Name,
+				Description:    body.Description,
+				OrgID:          &body.OrgID,
+				RetentionRules: body.RetentionRules,
+			}
+			writeJSON(w, http.StatusCreated, f.buckets[id])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	bucket, ok := f.buckets[path[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "bucket not found")
+		return
+	}
+
+	if len(path) == 1 {
+		switch req.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, bucket)
+		case http.MethodPatch:
+			body := domain.PatchBucketRequest{}
+			if !readJSON(w, req, &body) {
+				return
+			}
+			if body.Name != nil {
+				bucket.Name = *body.Name
+			}
+			if body.Description != nil {
+				bucket.Description = body.Description
+			}
+			if body.RetentionRules != nil {
+				rules := domain.RetentionRules{}
+				for _, rule := range *body.RetentionRules {
+					everySeconds := int64(0)
+					if rule.EverySeconds != nil {
+						everySeconds = *rule.EverySeconds
+					}
+					rules = append(rules, domain.RetentionRule{
+						EverySeconds:              everySeconds,
+						ShardGroupDurationSeconds: rule.ShardGroupDurationSeconds,
+						Type:                      domain.RetentionRuleType(rule.Type),
+					})
+				}
+				bucket.RetentionRules = rules
+			}
+			writeJSON(w, http.StatusOK, bucket)
+		case http.MethodDelete:
+			delete(f.buckets, *bucket.Id)
+			delete(f.bucketLabels, *bucket.Id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	if path[1] != "labels" {
+		writeError(w, http.StatusNotFound, "path not found")
+		return
+	}
+
+	switch {
+	case len(path) == 2 && req.Method == http.MethodGet:
+		labels := domain.Labels{}
+		for _, id := range f.bucketLabels[*bucket.Id] {
+			if label, ok := f.labels[id]; ok {
+				labels = append(labels, *label)
+			}
+		}
+		writeJSON(w, http.StatusOK, domain.LabelsResponse{Labels: &labels})
+	case len(path) == 2 && req.Method == http.MethodPost:
+		body := domain.LabelMapping{}
+		if !readJSON(w, req, &body) {
+			return
+		}
+		label, ok := f.labels[*body.LabelID]
+		if !ok {
+			writeError(w, http.StatusNotFound, "label not found")
+			return
+		}
+		f.bucketLabels[*bucket.Id] = append(f.bucketLabels[*bucket.Id], *body.LabelID)
+		writeJSON(w, http.StatusCreated, domain.LabelResponse{Label: label})
+	case len(path) == 3 && req.Method == http.MethodDelete:
+		ids := f.bucketLabels[*bucket.Id][:0]
+		for _, id := range f.bucketLabels[*bucket.Id] {
+			if id != path[2] {
+				ids = append(ids, id)
+			}
+		}
+		f.bucketLabels[*bucket.Id] = ids
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (f *fakeInfluxdb) serveAuthorizations(w http.ResponseWriter, req *http.Request, path []string) {
+	query := req.URL.Query()
+
+	if len(path) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			authorizations := []domain.Authorization{}
+			for _, authorization := range f.authorizations {
+				if orgId := query.Get("orgID"); len(orgId) > 0 && *authorization.OrgID != orgId {
+					continue
+				}
+				authorizations = append(authorizations, *authorization)
+			}
+			sort.Slice(authorizations, func(i, j int) bool {
+				return *authorizations[i].Id < *authorizations[j].Id
+			})
+			writeJSON(w, http.StatusOK, domain.Authorizations{Authorizations: &authorizations})
+		case http.MethodPost:
+			body := domain.AuthorizationPostRequest{}
+			if !readJSON(w, req, &body) {
+				return
+			}
+			if body.OrgID == nil {
+				writeError(w, http.StatusBadRequest, "org id required")
+				return
+			}
+			if _, ok := f.orgs[*body.OrgID]; !ok {
+				writeError(w, http.StatusNotFound, "organization not found")
+				return
+			}
+			id := f.newId()
+			token := "token-" + id
+			status := domain.AuthorizationUpdateRequestStatusActive
+			if body.Status != nil {
+				status = *body.Status
+			}
+			f.authorizations[id] = &domain.Authorization{
+				AuthorizationUpdateRequest: domain.AuthorizationUpdateRequest{
+					Description: body.Description,
+					Status:      &status,
+				},
+				Id:          &id,
+				OrgID:       body.OrgID,
+				Permissions: body.Permissions,
+				Token:       &token,
+				UserID:      body.UserID,
+			}
+			writeJSON(w, http.StatusCreated, f.authorizations[id])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	authorization, ok := f.authorizations[path[0]]
+	if !ok || len(path) > 1 {
+		writeError(w, http.StatusNotFound, "authorization not found")
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, authorization)
+	case http.MethodPatch:
+		body := domain.AuthorizationUpdateRequest{}
+		if !readJSON(w, req, &body) {
+			return
+		}
+		if body.Status != nil {
+			authorization.Status = body.Status
+		}
+		if body.Description != nil {
+			authorization.Description = body.Description
+		}
+		writeJSON(w, http.StatusOK, authorization)
+	case http.MethodDelete:
+		delete(f.authorizations, *authorization.Id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (f *fakeInfluxdb) serveUsers(w http.ResponseWriter, req *http.Request, path []string) {
+	query := req.URL.Query()
+
+	if len(path) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			users := []domain.UserResponse{}
+			for _, user := range f.users {
+				if name := query.Get("name"); len(name) > 0 && user.Name != name {
+					continue
+				}
+				if id := query.Get("id"); len(id) > 0 && *user.Id != id {
+					continue
+				}
+				users = append(users, userResponse(user))
+			}
+			writeJSON(w, http.StatusOK, domain.Users{Users: &users})
+		case http.MethodPost:
+			body := domain.User{}
+			if !readJSON(w, req, &body) {
+				return
+			}
+			for _, user := range f.users {
+				if user.Name == body.Name {
+					writeError(w, http.StatusUnprocessableEntity, "user with name already exists")
+					return
+				}
+			}
+			id := f.newId()
+			f.users[id] = &domain.User{Id: &id, Name: body.Name, Status: body.Status}
+			writeJSON(w, http.StatusCreated, userResponse(f.users[id]))
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	user, ok := f.users[path[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	switch {
+	case len(path) == 1 && req.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, userResponse(user))
+	case len(path) == 1 && req.Method == http.MethodPatch:
+		body := domain.User{}
+		if !readJSON(w, req, &body) {
+			return
+		}
+		user.Name = body.Name
+		if body.Status != nil {
+			user.Status = body.Status
+		}
+		writeJSON(w, http.StatusOK, userResponse(user))
+	case len(path) == 1 && req.Method == http.MethodDelete:
+		delete(f.users, *user.Id)
+		delete(f.passwords, *user.Id)
+		for _, members := range f.members {
+			delete(members, *user.Id)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(path) == 2 && path[1] == "password" && req.Method == http.MethodPost:
+		body := domain.PasswordResetBody{}
+		if !readJSON(w, req, &body) {
+			return
+		}
+		f.passwords[*user.Id] = body.Password
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (f *fakeInfluxdb) serveLabels(w http.ResponseWriter, req *http.Request, path []string) {
+	query := req.URL.Query()
+
+	if len(path) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			labels := domain.Labels{}
+			for _, label := range f.labels {
+				if orgId := query.Get("orgID"); len(orgId) > 0 && *label.OrgID != orgId {
+					continue
+				}
+				labels = append(labels, *label)
+			}
+			writeJSON(w, http.StatusOK, domain.LabelsResponse{Labels: &labels})
+		case http.MethodPost:
+			body := domain.LabelCreateRequest{}
+			if !readJSON(w, req, &body) {
+				return
+			}
+			id := f.newId()
+			f.labels[id] = &domain.Label{Id: &id, Name: &body.Name, OrgID: &body.OrgID}
+			writeJSON(w, http.StatusCreated, domain.LabelResponse{Label: f.labels[id]})
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	label, ok := f.labels[path[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "label not found")
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, domain.LabelResponse{Label: label})
+	case http.MethodDelete:
+		delete(f.labels, *label.Id)
+		for bucketId, ids := range f.bucketLabels {
+			kept := ids[:0]
+			for _, id := range ids {
+				if id != *label.Id {
+					kept = append(kept, id)
+				}
+			}
+			f.bucketLabels[bucketId] = kept
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// userResponse converts user to its api response
+func userResponse(user *domain.User) domain.UserResponse {
+	response := domain.UserResponse{Id: user.Id, Name: user.Name}
+	if user.Status != nil {
+		status := domain.UserResponseStatus(*user.Status)
+		response.Status = &status
+	}
+	return response
+}
+
+// readJSON decodes request body responding with bad request on failure
+func readJSON(w http.ResponseWriter, req *http.Request, body interface{}) bool {
+	if err := json.NewDecoder(req.Body).Decode(body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// writeJSON responds with json encoded body
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError responds with influxdb error
+func writeError(w http.ResponseWriter, status int, message string) {
+	code := domain.ErrorCodeInternalError
+	switch status {
+	case http.StatusNotFound:
+		code = domain.ErrorCodeNotFound
+	case http.StatusBadRequest:
+		code = domain.ErrorCodeInvalid
+	case http.StatusUnprocessableEntity:
+		code = domain.ErrorCodeConflict
+	case http.StatusMethodNotAllowed:
+		code = domain.ErrorCodeMethodNotAllowed
+	case http.StatusServiceUnavailable:
+		code = domain.ErrorCodeUnavailable
+	}
+	writeJSON(w, status, domain.Error{Code: code, Message: message})
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
@@ -0,0 +1,416 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	influxdb "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// organizationRole lists, adds and removes users holding a role in an org,
+// which is either membership or ownership
+type organizationRole struct {
+	list   func(ctx context.Context, orgId string) ([]string, error)
+	add    func(ctx context.Context, orgId, userId string) error
+	remove func(ctx context.Context, orgId, userId string) error
+}
+
+// getOrganizationRoles returns member and owner roles keyed by role name
+func getOrganizationRoles(orgApi api.OrganizationsAPI) map[string]organizationRole {
+	return map[string]organizationRole{
+		influxdbv1beta1.UserRoleMember: {
+			list: func(ctx context.Context, orgId string) ([]string, error) {
+				members, err := orgApi.GetMembersWithID(ctx, orgId)
+				if err != nil {
+					return nil, err
+				}
+				if members == nil {
+					return nil, fmt.Errorf("received nil members")
+				}
+
+				ids := make([]string, 0, len(*members))
+				for _, member := range *members {
+					if member.Id != nil {
+						ids = append(ids, *member.Id)
+					}
+				}
+				return ids, nil
+			},
+			add: func(ctx context.Context, orgId, userId string) error {
+				_, err := orgApi.AddMemberWithID(ctx, orgId, userId)
+				return err
+			},
+			remove: orgApi.RemoveMemberWithID,
+		},
+		influxdbv1beta1.UserRoleOwner: {
+			list: func(ctx context.Context, orgId string) ([]string, error) {
+				owners, err := orgApi.GetOwnersWithID(ctx, orgId)
+				if err != nil {
+					return nil, err
+				}
+				if owners == nil {
+					return nil, fmt.Errorf("received nil owners")
+				}
+
+				ids := make([]string, 0, len(*owners))
+				for _, owner := range *owners {
+					if owner.Id != nil {
+						ids = append(ids, *owner.Id)
+					}
+				}
+				return ids, nil
+			},
+			add: func(ctx context.Context, orgId, userId string) error {
+				_, err := orgApi.AddOwnerWithID(ctx, orgId, userId)
+				return err
+			},
+			remove: orgApi.RemoveOwnerWithID,
+		},
+	}
+}
+
+// getMemberUserIDs resolves influxdb user ids of members listed by name
+// or by reference to User objects in the namespace
+func getMemberUserIDs(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+	newClient influxdb.Client,
+	members []influxdbv1beta1.OrganizationMember,
+) (map[string]struct{}, error) {
+	ids := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		if member.UserRef != nil {
+			user := &influxdbv1beta1.User{}
+			if err := c.Get(ctx, types.NamespacedName{
+				Namespace: namespace,
+				Name:      member.UserRef.Name,
+			}, user); err != nil {
+				return nil, fmt.Errorf("failed to get user %s: %w", member.UserRef.Name, err)
+			}
+
+			if len(user.Status.ID) == 0 {
+				return nil, fmt.Errorf("%w: user object %s has no influxdb id yet", UserNotFound, user.Name)
+			}
+
+			ids[user.Status.ID] = struct{}{}
+			continue
+		}
+
+		user, err := findUserByName(ctx, newClient, member.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user %s: %w", member.Name, err)
+		}
+
+		if user == nil {
+			return nil, fmt.Errorf("%w: %s", UserNotFound, member.Name)
+		}
+
+		if user.Id == nil {
+			return nil, fmt.Errorf("received invalid id for user %s", member.Name)
+		}
+
+		ids[*user.Id] = struct{}{}
+	}
+
+	return ids, nil
+}
+
+// findUserByName looks up user by name returning nil user if none exists.
+// Users api of influxdb client only searches the first page of users
+func findUserByName(ctx context.Context, newClient influxdb.Client, name string) (*domain.User, error) {
+	response, err := domain.NewClientWithResponses(newClient.HTTPService()).GetUsersWithResponse(
+		ctx,
+		&domain.GetUsersParams{
+			Name: &name,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.JSONDefault != nil {
+		if response.StatusCode() == 404 {
+			return nil, nil
+		}
+		return nil, domain.ErrorToHTTPError(response.JSONDefault, response.StatusCode())
+	}
+
+	if response.JSON200 == nil || response.JSON200.Users == nil {
+		return nil, nil
+	}
+
+	for _, user := range *response.JSON200.Users {
+		if user.Name == name {
+			return &domain.User{
+				Id:      user.Id,
+				Name:    user.Name,
+				OauthID: user.OauthID,
+				Status:  (*domain.UserStatus)(user.Status),
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// getUserGrantedRoles returns ids of users holding roles in the org granted
+// by User objects in the namespace keyed by role
+func getUserGrantedRoles(
+	ctx context.Context,
+	c client.Client,
+	namespace, orgId string,
+) (map[string]map[string]struct{}, error) {
+	users := &influxdbv1beta1.UserList{}
+	if err := c.List(ctx, users, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	granted := make(map[string]map[string]struct{})
+	for _, user := range users.Items {
+		for _, membership := range user.Status.Memberships {
+			if membership.OrgID != orgId || len(user.Status.ID) == 0 {
+				continue
+			}
+
+			if granted[membership.Role] == nil {
+				granted[membership.Role] = make(map[string]struct{})
+			}
+			granted[membership.Role][user.Status.ID] = struct{}{}
+		}
+	}
+
+	return granted, nil
+}
+
+// reconcileOrganizationMembers adds members and owners missing from the org and,
+// if pruning is enabled, removes those not listed in the spec. It reports
+// whether membership changed
+func reconcileOrganizationMembers(
+	ctx context.Context,
+	c client.Client,
+	object *influxdbv1beta1.Organization,
+	newClient influxdb.Client,
+	orgId string,
+) (bool, error) {
+	spec := &object.Spec
+	if len(spec.Members) == 0 && len(spec.Owners) == 0 && !spec.PruneMembers {
+		return false, nil
+	}
+
+	// user the operator authenticates as keeps its access to the org, so do
+	// users granted roles in the org by User objects
+	var selfId string
+	var granted map[string]map[string]struct{}
+	if spec.PruneMembers {
+		self, err := newClient.UsersAPI().Me(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to get current user: %w", err)
+		}
+		if self != nil && self.Id != nil {
+			selfId = *self.Id
+		}
+
+		granted, err = getUserGrantedRoles(ctx, c, object.Namespace, orgId)
+		if err != nil {
+			return false, fmt.Errorf("failed to list users: %w", err)
+		}
+	}
+
+	roles := getOrganizationRoles(newClient.OrganizationsAPI())
+
+	var changed bool
+	for _, roleMembers := range []struct {
+		name    string
+		members []influxdbv1beta1.OrganizationMember
+	}{
+		{name: influxdbv1beta1.UserRoleMember, members: spec.Members},
+		{name: influxdbv1beta1.UserRoleOwner, members: spec.Owners},
+	} {
+		role := roles[roleMembers.name]
+
+		desired, err := getMemberUserIDs(ctx, c, object.Namespace, newClient, roleMembers.members)
+		if err != nil {
+			return changed, err
+		}
+
+		current, err := role.list(ctx, orgId)
+		if err != nil {
+			return changed, fmt.Errorf("failed to list %ss: %w", roleMembers.name, err)
+		}
+
+		existing := make(map[string]struct{}, len(current))
+		for _, userId := range current {
+			existing[userId] = struct{}{}
+
+			if _, ok := desired[userId]; ok || !spec.PruneMembers || userId == selfId {
+				continue
+			}
+
+			if _, ok := granted[roleMembers.name][userId]; ok {
+				continue
+			}
+
+			if err := role.remove(ctx, orgId, userId); err != nil && !isHttpStatusCode(err, 404) {
+				return changed, fmt.Errorf("failed to remove %s %s: %w", roleMembers.name, userId, err)
+			}
+			changed = true
+		}
+
+		for userId := range desired {
+			if _, ok := existing[userId]; ok {
+				continue
+			}
+
+			if err := role.add(ctx, orgId, userId); err != nil {
+				return changed, fmt.Errorf("failed to add %s %s: %w", roleMembers.name, userId, err)
+			}
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+// getUserMemberships resolves influxdb org ids of organizations listed in
+// the user spec by name or by reference to Organization objects
+func getUserMemberships(
+	ctx context.Context,
+	c client.Client,
+	newClient influxdb.Client,
+	object *influxdbv1beta1.User,
+) ([]influxdbv1beta1.UserMembershipStatus, error) {
+	memberships := make([]influxdbv1beta1.UserMembershipStatus, 0, len(object.Spec.Organizations))
+	for _, membership := range object.Spec.Organizations {
+		role := membership.Role
+		if len(role) == 0 {
+			role = influxdbv1beta1.UserRoleMember
+		}
+
+		var orgId string
+		if membership.OrganizationRef != nil {
+			organization := &influxdbv1beta1.Organization{}
+			if err := c.Get(ctx, types.NamespacedName{
+				Namespace: object.Namespace,
+				Name:      membership.OrganizationRef.Name,
+			}, organization); err != nil {
+				return nil, fmt.Errorf("failed to get organization %s: %w", membership.OrganizationRef.Name, err)
+			}
+
+			if len(organization.Status.OrgID) == 0 {
+				return nil, fmt.Errorf("%w: organization object %s has no influxdb id yet",
+					OrganizationNotFound, organization.Name)
+			}
+
+			orgId = organization.Status.OrgID
+		} else {
+			organization, err := newClient.OrganizationsAPI().FindOrganizationByName(ctx, membership.Name)
+			if err != nil {
+				if isHttpStatusCode(err, 404) {
+					return nil, fmt.Errorf("%w: %s", OrganizationNotFound, membership.Name)
+				}
+				return nil, fmt.Errorf("failed to find organization %s: %w", membership.Name, err)
+			}
+
+			if organization == nil || organization.Id == nil || len(*organization.Id) == 0 {
+				return nil, fmt.Errorf("received nil org pointer or invalid id for organization %s", membership.Name)
+			}
+
+			orgId = *organization.Id
+		}
+
+		memberships = append(memberships, influxdbv1beta1.UserMembershipStatus{
+			OrgID: orgId,
+			Role:  role,
+		})
+	}
+
+	return memberships, nil
+}
+
+// reconcileUserMemberships grants the user desired organization roles missing
+// from influxdb and revokes roles previously granted that are no longer desired.
+// It returns roles granted by the object, which leave out desired roles the user
+// held before the object granted them, and reports whether memberships changed
+func reconcileUserMemberships(
+	ctx context.Context,
+	newClient influxdb.Client,
+	userId string,
+	current, desired []influxdbv1beta1.UserMembershipStatus,
+) ([]influxdbv1beta1.UserMembershipStatus, bool, error) {
+	roles := getOrganizationRoles(newClient.OrganizationsAPI())
+
+	previous := make(map[influxdbv1beta1.UserMembershipStatus]struct{}, len(current))
+	for _, membership := range current {
+		previous[membership] = struct{}{}
+	}
+
+	var changed bool
+	var granted []influxdbv1beta1.UserMembershipStatus
+	wanted := make(map[influxdbv1beta1.UserMembershipStatus]struct{}, len(desired))
+	for _, membership := range desired {
+		wanted[membership] = struct{}{}
+
+		role, ok := roles[membership.Role]
+		if !ok {
+			return nil, changed, fmt.Errorf("invalid role %s", membership.Role)
+		}
+
+		userIds, err := role.list(ctx, membership.OrgID)
+		if err != nil {
+			return nil, changed, fmt.Errorf("failed to list %ss of organization %s: %w",
+				membership.Role, membership.OrgID, err)
+		}
+
+		// roles held before are left alone, unless granted by the object earlier
+		if containsString(userIds, userId) {
+			if _, ok := previous[membership]; ok {
+				granted = append(granted, membership)
+			}
+			continue
+		}
+
+		if err := role.add(ctx, membership.OrgID, userId); err != nil {
+			return nil, changed, fmt.Errorf("failed to add %s to organization %s: %w",
+				membership.Role, membership.OrgID, err)
+		}
+		granted = append(granted, membership)
+		changed = true
+	}
+
+	for _, membership := range current {
+		if _, ok := wanted[membership]; ok {
+			continue
+		}
+
+		role, ok := roles[membership.Role]
+		if !ok {
+			continue
+		}
+
+		// organization may have been deleted in the meantime
+		if err := role.remove(ctx, membership.OrgID, userId); err != nil && !isHttpStatusCode(err, 404) {
+			return nil, changed, fmt.Errorf("failed to remove %s from organization %s: %w",
+				membership.Role, membership.OrgID, err)
+		}
+		changed = true
+	}
+
+	return granted, changed, nil
+}
+
+// containsString checks if value is in values
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
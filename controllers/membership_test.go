@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+)
+
+func TestReconcileUserMemberships(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeInfluxdb(t)
+	teamId, otherId := server.CreateOrg("team"), server.CreateOrg("other")
+	userId := server.CreateUser("alice")
+	server.AddMember(teamId, userId)
+
+	pooled, err := (*ClientPool)(nil).Get(ctx, newFakeClient(server.TokenSecret("team")),
+		server.Config("team", "default", "team"))
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	defer pooled.Close()
+
+	teamMember := influxdbv1beta1.UserMembershipStatus{OrgID: teamId, Role: influxdbv1beta1.UserRoleMember}
+	otherOwner := influxdbv1beta1.UserMembershipStatus{OrgID: otherId, Role: influxdbv1beta1.UserRoleOwner}
+
+	granted, changed, err := reconcileUserMemberships(ctx, pooled, userId, nil,
+		[]influxdbv1beta1.UserMembershipStatus{teamMember, otherOwner})
+	if err != nil {
+		t.Fatalf("failed to reconcile memberships: %v", err)
+	}
+	if !changed {
+		t.Errorf("expected memberships to change")
+	}
+	// membership held before is not granted by the object
+	if want := []influxdbv1beta1.UserMembershipStatus{otherOwner}; !reflect.DeepEqual(granted, want) {
+		t.Errorf("granted = %v, want %v", granted, want)
+	}
+
+	granted, changed, err = reconcileUserMemberships(ctx, pooled, userId, granted,
+		[]influxdbv1beta1.UserMembershipStatus{teamMember, otherOwner})
+	if err != nil {
+		t.Fatalf("failed to reconcile memberships: %v", err)
+	}
+	if changed {
+		t.Errorf("expected memberships not to change")
+	}
+	if want := []influxdbv1beta1.UserMembershipStatus{otherOwner}; !reflect.DeepEqual(granted, want) {
+		t.Errorf("granted = %v, want %v", granted, want)
+	}
+
+	granted, _, err = reconcileUserMemberships(ctx, pooled, userId, granted, nil)
+	if err != nil {
+		t.Fatalf("failed to reconcile memberships: %v", err)
+	}
+	if len(granted) != 0 {
+		t.Errorf("granted = %v, want none", granted)
+	}
+	if got := server.Members(otherId, fakeOwnerRole); len(got) != 0 {
+		t.Errorf("owners of other org = %v, want none", got)
+	}
+	if got, want := server.Members(teamId, domain.ResourceMemberRoleMember), []string{userId}; !reflect.DeepEqual(got, want) {
+		t.Errorf("members of team org = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// orgAdminResourceTypes lists resource types the org admin token can read and write.
+// Authorizations allow the generated config to issue further tokens in the org
+var orgAdminResourceTypes = []string{
+	influxdbv1beta1.ResourceTypeAuthorizations,
+	influxdbv1beta1.ResourceTypeBuckets,
+	influxdbv1beta1.ResourceTypeOrgs,
+}
+
+// reconcileGeneratedConfig creates or updates Config generated from config template of
+// the organization. When an admin token is requested, a second Config reaching the
+// organization with credentials of the organization config is generated to issue the
+// Token, which the generated config then authenticates with. It reports whether
+// any of the generated objects changed
+func reconcileGeneratedConfig(
+	ctx context.Context,
+	c client.Client,
+	scheme *runtime.Scheme,
+	object *influxdbv1beta1.Organization,
+	config *influxdbv1beta1.Config,
+) (bool, error) {
+	template := object.Spec.ConfigTemplate
+	if template == nil {
+		return false, nil
+	}
+
+	// credentials and tls settings of a cluster config resolve in its own namespace,
+	// hence configs are only generated from a config in the namespace of the organization
+	if configRef := object.GetConfigRef(); configRef.Kind != influxdbv1beta1.ConfigKind {
+		return false, fmt.Errorf("%w: config template requires a config in namespace %s, not %s %s",
+			ConfigNotAllowed, object.Namespace, configRef.Kind, configRef.Name)
+	}
+
+	// generated config reaches influxdb the same way as the config of the organization
+	spec := *config.Spec.DeepCopy()
+	spec.OrgName = object.GetOrgName()
+	spec.OrgID = object.Status.OrgID
+	spec.AllowBuckets = false
+	if spec.BasicAuth == nil && len(spec.TokenSecretNamespace) == 0 {
+		spec.TokenSecretNamespace = config.Namespace
+	}
+	if spec.BasicAuth != nil && len(spec.BasicAuth.SecretNamespace) == 0 {
+		spec.BasicAuth.SecretNamespace = config.Namespace
+	}
+
+	var changed bool
+	if template.AdminToken != nil {
+		adminConfigChanged, err := createOrUpdateGeneratedConfig(ctx, c, scheme, object, object.GetAdminConfigName(), spec)
+		if err != nil {
+			return changed, err
+		}
+
+		tokenChanged, err := createOrUpdateAdminToken(ctx, c, scheme, object)
+		if err != nil {
+			return changed, err
+		}
+
+		changed = adminConfigChanged || tokenChanged
+
+		spec.TokenSecretName = object.GetAdminTokenSecretName()
+		spec.TokenSecretNamespace = object.Namespace
+		spec.TokenSecretKey = keyToken
+		spec.BasicAuth = nil
+	}
+
+	spec.AllowBuckets = template.AllowBuckets
+	configChanged, err := createOrUpdateGeneratedConfig(ctx, c, scheme, object, object.GetGeneratedConfigName(), spec)
+	if err != nil {
+		return changed, err
+	}
+
+	return changed || configChanged, nil
+}
+
+// createOrUpdateGeneratedConfig ensures that Config of given name is owned by
+// the organization and has given spec
+func createOrUpdateGeneratedConfig(
+	ctx context.Context,
+	c client.Client,
+	scheme *runtime.Scheme,
+	object *influxdbv1beta1.Organization,
+	name string,
+	spec influxdbv1beta1.ConfigSpec,
+) (bool, error) {
+	generated := &influxdbv1beta1.Config{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      name,
+			Namespace: object.Namespace,
+		},
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, c, generated, func() error {
+		if err := checkGeneratedObjectOwner(object, generated); err != nil {
+			return err
+		}
+
+		// org id of a generated config follows the organization, which gets
+		// a new id when recreated in influxdb
+		v12.SetMetaDataAnnotation(&generated.ObjectMeta, influxdbv1beta1.AnnotationAllowTargetChange, "true")
+		generated.Spec = spec
+		return controllerutil.SetControllerReference(object, generated, scheme)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to generate config %s: %w", name, err)
+	}
+
+	return result != controllerutil.OperationResultNone, nil
+}
+
+// createOrUpdateAdminToken ensures that org admin Token issued via the admin config
+// is owned by the organization. Token follows deletion policy of the organization
+func createOrUpdateAdminToken(
+	ctx context.Context,
+	c client.Client,
+	scheme *runtime.Scheme,
+	object *influxdbv1beta1.Organization,
+) (bool, error) {
+	token := &influxdbv1beta1.Token{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      object.GetAdminTokenSecretName(),
+			Namespace: object.Namespace,
+		},
+	}
+
+	permissions := getOrgAdminPermissions(object.GetOrgName())
+
+	result, err := controllerutil.CreateOrUpdate(ctx, c, token, func() error {
+		if err := checkGeneratedObjectOwner(object, token); err != nil {
+			return err
+		}
+
+		token.Spec.Permissions = permissions
+		token.Spec.SecretName = object.GetAdminTokenSecretName()
+		token.Spec.ConfigName = object.GetAdminConfigName()
+		token.Spec.ConfigRef = nil
+		token.Spec.DeletionPolicy = object.Spec.DeletionPolicy
+		return controllerutil.SetControllerReference(object, token, scheme)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to generate admin token %s: %w", token.Name, err)
+	}
+
+	return result != controllerutil.OperationResultNone, nil
+}
+
+// getOrgAdminPermissions returns permissions of the org admin token. Orgs
+// permissions name the org so that they are scoped to it rather than to all orgs
+func getOrgAdminPermissions(orgName string) []influxdbv1beta1.Permission {
+	permissions := make([]influxdbv1beta1.Permission, 0, len(orgAdminResourceTypes)*2)
+	for _, resourceType := range orgAdminResourceTypes {
+		var resourceName string
+		if resourceType == influxdbv1beta1.ResourceTypeOrgs {
+			resourceName = orgName
+		}
+
+		for _, permissionType := range []string{influxdbv1beta1.PermissionRead, influxdbv1beta1.PermissionWrite} {
+			permissions = append(permissions, influxdbv1beta1.Permission{
+				ResourceName:   resourceName,
+				ResourceType:   resourceType,
+				PermissionType: permissionType,
+			})
+		}
+	}
+
+	return permissions
+}
+
+// checkGeneratedObjectOwner refuses taking over existing objects
+// not generated for the organization
+func checkGeneratedObjectOwner(object *influxdbv1beta1.Organization, generated client.Object) error {
+	if len(generated.GetResourceVersion()) == 0 || v12.IsControlledBy(generated, object) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s exists and is not owned by organization %s",
+		ResourceNotOwned, generated.GetName(), object.Name)
+}
+
+// listGeneratedObjects lists configs and admin tokens generated for the
+// organization, tokens first, regardless of its current config template
+func listGeneratedObjects(ctx context.Context, c client.Client, object *influxdbv1beta1.Organization) ([]client.Object, error) {
+	tokens := &influxdbv1beta1.TokenList{}
+	if err := c.List(ctx, tokens, client.InNamespace(object.Namespace)); err != nil {
+		return nil, err
+	}
+
+	configs := &influxdbv1beta1.ConfigList{}
+	if err := c.List(ctx, configs, client.InNamespace(object.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var generated []client.Object
+	for i := range tokens.Items {
+		if v12.IsControlledBy(&tokens.Items[i], object) {
+			generated = append(generated, &tokens.Items[i])
+		}
+	}
+	for i := range configs.Items {
+		if v12.IsControlledBy(&configs.Items[i], object) {
+			generated = append(generated, &configs.Items[i])
+		}
+	}
+
+	return generated, nil
+}
+
+// pruneGeneratedObjects removes generated configs and admin token that the config
+// template no longer asks for, such as after the template or its admin token was
+// removed. Per orphan deletion policy they are released instead of deleted. Admin
+// config is deleted only after the admin token issued via it is gone, so that
+// the token can revoke its authorization. It returns names of removed objects
+func pruneGeneratedObjects(
+	ctx context.Context,
+	c client.Client,
+	object *influxdbv1beta1.Organization,
+	policy string,
+) ([]string, error) {
+	desired := make(map[string]struct{})
+	if object.Spec.ConfigTemplate != nil {
+		desired["config/"+object.GetGeneratedConfigName()] = struct{}{}
+		if object.Spec.ConfigTemplate.AdminToken != nil {
+			desired["config/"+object.GetAdminConfigName()] = struct{}{}
+			desired["token/"+object.GetAdminTokenSecretName()] = struct{}{}
+		}
+	}
+
+	generated, err := listGeneratedObjects(ctx, c, object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list generated objects: %w", err)
+	}
+
+	var removed []string
+	// configs issuing admin tokens that are being deleted
+	issuing := make(map[string]struct{})
+	for _, generatedObject := range generated {
+		var key string
+		switch generated := generatedObject.(type) {
+		case *influxdbv1beta1.Token:
+			key = "token/" + generated.Name
+			if _, ok := desired[key]; !ok && policy != influxdbv1beta1.DeletionPolicyOrphan {
+				issuing[generated.Spec.ConfigName] = struct{}{}
+			}
+		default:
+			key = "config/" + generated.GetName()
+			if _, ok := issuing[generated.GetName()]; ok {
+				continue
+			}
+		}
+
+		if _, ok := desired[key]; ok {
+			continue
+		}
+
+		if policy == influxdbv1beta1.DeletionPolicyOrphan {
+			if err := releaseGeneratedObject(ctx, c, object, generatedObject); err != nil {
+				return removed, err
+			}
+			removed = append(removed, key)
+			continue
+		}
+
+		if generatedObject.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		if err := c.Delete(ctx, generatedObject); err != nil && !apimachineryerrors.IsNotFound(err) {
+			return removed, fmt.Errorf("failed to delete generated %s: %w", key, err)
+		}
+		removed = append(removed, key)
+	}
+
+	return removed, nil
+}
+
+// releaseGeneratedObjects removes owner references of the organization from
+// generated configs and admin token, so they are not garbage collected
+func releaseGeneratedObjects(ctx context.Context, c client.Client, object *influxdbv1beta1.Organization) error {
+	generated, err := listGeneratedObjects(ctx, c, object)
+	if err != nil {
+		return err
+	}
+
+	for _, generatedObject := range generated {
+		if err := releaseGeneratedObject(ctx, c, object, generatedObject); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseGeneratedObject removes owner reference of the organization from the object
+func releaseGeneratedObject(
+	ctx context.Context,
+	c client.Client,
+	object *influxdbv1beta1.Organization,
+	generatedObject client.Object,
+) error {
+	var ownerReferences []v12.OwnerReference
+	for _, ownerReference := range generatedObject.GetOwnerReferences() {
+		if ownerReference.UID != object.UID {
+			ownerReferences = append(ownerReferences, ownerReference)
+		}
+	}
+
+	if len(ownerReferences) == len(generatedObject.GetOwnerReferences()) {
+		return nil
+	}
+
+	generatedObject.SetOwnerReferences(ownerReferences)
+	if err := c.Update(ctx, generatedObject); err != nil && !apimachineryerrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestGetOrgAdminPermissions(t *testing.T) {
+	orgId, orgName := "org-id", "org"
+	organization := &domain.Organization{Id: &orgId, Name: orgName}
+
+	permissions := getPermissions(getOrgAdminPermissions(orgName), organization, nil)
+	if len(permissions) != len(orgAdminResourceTypes)*2 {
+		t.Fatalf("got %d permissions, want %d", len(permissions), len(orgAdminResourceTypes)*2)
+	}
+
+	for _, permission := range permissions {
+		resource := permission.Resource
+		switch string(resource.Type) {
+		case influxdbv1beta1.ResourceTypeOrgs:
+			// unscoped orgs permission would apply to every org
+			if got := stringValue(resource.Id); got != orgId {
+				t.Errorf("%s %s id = %q, want %q", permission.Action, resource.Type, got, orgId)
+			}
+		default:
+			if got := stringValue(resource.OrgID); got != orgId {
+				t.Errorf("%s %s org id = %q, want %q", permission.Action, resource.Type, got, orgId)
+			}
+			if resource.Id != nil {
+				t.Errorf("%s %s id = %q, want none", permission.Action, resource.Type, *resource.Id)
+			}
+		}
+	}
+}
+
+func TestPruneGeneratedObjects(t *testing.T) {
+	ctx := context.Background()
+
+	newOrganization := func() *influxdbv1beta1.Organization {
+		return &influxdbv1beta1.Organization{
+			ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "team", UID: "team-uid"},
+			Spec: influxdbv1beta1.OrganizationSpec{
+				ConfigTemplate: &influxdbv1beta1.OrganizationConfigTemplate{
+					AdminToken: &influxdbv1beta1.OrganizationAdminToken{},
+				},
+			},
+		}
+	}
+
+	// newGenerated returns objects generated for the organization with admin token
+	newGenerated := func(object *influxdbv1beta1.Organization) []client.Object {
+		generated := []client.Object{
+			&influxdbv1beta1.Config{ObjectMeta: metav1.ObjectMeta{
+				Name: object.GetGeneratedConfigName(), Namespace: object.Namespace}},
+			&influxdbv1beta1.Config{ObjectMeta: metav1.ObjectMeta{
+				Name: object.GetAdminConfigName(), Namespace: object.Namespace}},
+			&influxdbv1beta1.Token{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       object.GetAdminTokenSecretName(),
+					Namespace:  object.Namespace,
+					Finalizers: []string{finalizer},
+				},
+				Spec: influxdbv1beta1.TokenSpec{ConfigName: object.GetAdminConfigName()},
+			},
+		}
+		for _, generatedObject := range generated {
+			if err := controllerutil.SetControllerReference(object, generatedObject, scheme.Scheme); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return generated
+	}
+
+	t.Run("template kept", func(t *testing.T) {
+		object := newOrganization()
+		c := newFakeClient(newGenerated(object)...)
+
+		removed, err := pruneGeneratedObjects(ctx, c, object, influxdbv1beta1.DeletionPolicyDelete)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(removed) != 0 {
+			t.Errorf("removed = %v, want none", removed)
+		}
+	})
+
+	t.Run("admin token removed", func(t *testing.T) {
+		object := newOrganization()
+		generated := newGenerated(object)
+		c := newFakeClient(generated...)
+		object.Spec.ConfigTemplate.AdminToken = nil
+
+		removed, err := pruneGeneratedObjects(ctx, c, object, influxdbv1beta1.DeletionPolicyDelete)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"token/team-admin-token"}; !reflect.DeepEqual(removed, want) {
+			t.Errorf("removed = %v, want %v", removed, want)
+		}
+
+		// admin config is kept until the token revoked its authorization
+		if getObject(t, c, generated[1]) == nil {
+			t.Errorf("admin config deleted before admin token is gone")
+		}
+
+		token := getObject(t, c, generated[2])
+		token.SetFinalizers(nil)
+		if err := c.Update(ctx, token); err != nil {
+			t.Fatal(err)
+		}
+
+		removed, err = pruneGeneratedObjects(ctx, c, object, influxdbv1beta1.DeletionPolicyDelete)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"config/team-admin"}; !reflect.DeepEqual(removed, want) {
+			t.Errorf("removed = %v, want %v", removed, want)
+		}
+		if getObject(t, c, generated[0]) == nil {
+			t.Errorf("generated config deleted")
+		}
+	})
+
+	t.Run("template removed with orphan policy", func(t *testing.T) {
+		object := newOrganization()
+		generated := newGenerated(object)
+		c := newFakeClient(generated...)
+		object.Spec.ConfigTemplate = nil
+
+		removed, err := pruneGeneratedObjects(ctx, c, object, influxdbv1beta1.DeletionPolicyOrphan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(removed) != len(generated) {
+			t.Errorf("removed = %v, want all generated objects", removed)
+		}
+
+		for _, generatedObject := range generated {
+			current := getObject(t, c, generatedObject)
+			if current == nil {
+				t.Errorf("%s deleted", generatedObject.GetName())
+			} else if metav1.IsControlledBy(current, object) {
+				t.Errorf("%s not released", generatedObject.GetName())
+			}
+		}
+	})
+}
@@ -4,16 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
-	influxdb "github.com/influxdata/influxdb-client-go/v2"
 	"github.com/influxdata/influxdb-client-go/v2/api/http"
 	"github.com/influxdata/influxdb-client-go/v2/domain"
 	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
 	v1 "k8s.io/api/core/v1"
 	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
 	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -41,6 +40,9 @@ func (r *OrganizationReconciler) FinalizeStatus(ctx context.Context, clientObjec
 			Conditions: object.Status.Conditions,
 			Message:    "object is marked for deletion",
 			Reason:     reasonObjectMarkedForDeletion,
+			ID:         object.Status.ID,
+			OrgID:      object.Status.OrgID,
+			ConfigName: object.Status.ConfigName,
 		}
 		if err := r.Status().Update(ctx, object); err != nil {
 			reqLogger.Error(err, "failed to update object status")
@@ -68,65 +70,137 @@ func (r *OrganizationReconciler) FinalizeResources(ctx context.Context, clientOb
 		return err
 	}
 
+	// protected organizations are retained regardless of deletion policy
+	policy := getDeletionPolicy(object.Spec.DeletionPolicy, r.DefaultDeletionPolicy)
+	protected := r.Protection.IsProtectedOrg(object.GetOrgName())
+	if protected || policy != influxdbv1beta1.DeletionPolicyDelete {
+		for _, condition := range object.Status.Conditions {
+			if condition.Reason == reasonRetainedOrganization {
+				return nil
+			}
+		}
+
+		// orphan releases generated configs and admin token from garbage
+		// collection so that workloads using them continue to work
+		if policy == influxdbv1beta1.DeletionPolicyOrphan {
+			if err := releaseGeneratedObjects(ctx, r.Client, object); err != nil {
+				reqLogger.Error(err, "failed to release generated objects")
+				return err
+			}
+		}
+
+		message := fmt.Sprintf("retained influxdb organization per %s deletion policy", policy)
+		if protected {
+			message = "retained protected influxdb organization"
+		}
+		reqLogger.Info(message)
+		r.Recorder.Event(object, v1.EventTypeNormal, reasonRetainedOrganization, message)
+
+		condition := v12.Condition{
+			Type:               conditionTypeInfluxdb,
+			Status:             v12.ConditionTrue,
+			ObservedGeneration: 0,
+			LastTransitionTime: v12.Time{Time: time.Now()},
+			Reason:             reasonRetainedOrganization,
+			Message:            message,
+		}
+		object.Status = influxdbv1beta1.OrganizationStatus{
+			Phase:      object.Status.Phase,
+			Conditions: append(object.Status.Conditions, condition),
+			Message:    message,
+			Reason:     reasonRetainedOrganization,
+			ID:         object.Status.ID,
+			OrgID:      object.Status.OrgID,
+			ConfigName: object.Status.ConfigName,
+		}
+
+		if err := r.Status().Update(ctx, object); err != nil {
+			reqLogger.Error(err, "failed to update object status")
+			return err
+		} else {
+			reqLogger.Info("updated object status")
+			return ObjectUpdated
+		}
+	}
+
 	// read config with influxdb info
-	config := &influxdbv1beta1.Config{}
-	if err := r.Get(ctx, types.NamespacedName{
-		Namespace: req.Namespace,
-		Name:      object.Spec.ConfigName,
-	}, config); err != nil {
+	config, err := getConfig(ctx, r.Client, req.Namespace, object.GetConfigRef())
+	if err != nil {
 		if apimachineryerrors.IsNotFound(err) {
 			reqLogger.Info("influxdb config not found, skipping deleting resources")
 			return nil
 		}
+		if errors.Is(err, ConfigNotAllowed) {
+			reqLogger.Info("influxdb config not allowed, skipping deleting resources")
+			return nil
+		}
 		reqLogger.Error(err, "failed to read influxdb config")
 		return err
 	}
 
-	// read secret with influxdb token
-	secret := &v1.Secret{}
-	if err := r.Get(
-		ctx,
-		types.NamespacedName{
-			Namespace: config.Spec.TokenSecretNamespace,
-			Name:      config.Spec.TokenSecretName,
-		},
-		secret,
-	); err != nil {
-		reqLogger.Error(err, "failed to read influxdb token")
+	newClient, err := r.Clients.Get(ctx, r.Client, config)
+	if err != nil {
+		reqLogger.Error(err, "failed to create influxdb client")
 		return err
 	}
-
-	newClient := influxdb.NewClient(config.Spec.Addr, string(secret.Data[keyToken]))
 	// always close client at the end
 	defer newClient.Close()
 
 	orgApi := newClient.OrganizationsAPI()
 
-	organization, err := orgApi.FindOrganizationByName(ctx, object.Name)
-	if err != nil {
-		httpErr := &http.Error{
-			StatusCode: 0,
-			Code:       "",
-			Message:    "",
-			Err:        nil,
-			RetryAfter: 0,
-		}
-		if errors.As(err, &httpErr) && httpErr.StatusCode == 404 {
-			reqLogger.Info("organization not found")
-			return nil
-		} else {
-			reqLogger.Error(err, "failed to find organization")
+	// organization id recorded in status is the primary way of identifying the org,
+	// lookup by name is only a fallback for objects without a recorded id
+	id := object.Status.ID
+	if len(id) == 0 {
+		organization, err := orgApi.FindOrganizationByName(ctx, object.GetOrgName())
+		if err != nil {
+			httpErr := &http.Error{
+				StatusCode: 0,
+				Code:       "",
+				Message:    "",
+				Err:        nil,
+				RetryAfter: 0,
+			}
+			if errors.As(err, &httpErr) && httpErr.StatusCode == 404 {
+				reqLogger.Info("organization not found")
+				return nil
+			} else {
+				reqLogger.Error(err, "failed to find organization")
+				return err
+			}
+		}
+
+		if organization == nil || organization.Id == nil || len(*organization.Id) == 0 {
+			err := fmt.Errorf("nil org pointer or invalid id")
+			reqLogger.Error(err, "failed to get valid org id")
 			return err
 		}
-	}
 
-	if organization == nil || organization.Id == nil || len(*organization.Id) == 0 {
-		err := fmt.Errorf("nil org pointer or invalid id")
-		reqLogger.Error(err, "failed to get valid org id")
-		return err
+		// never delete an organization that was not created or adopted by this object
+		owned, err := isOrganizationOwned(
+			ctx,
+			newClient,
+			*organization.Id,
+			getOwnerLabelName(object.Name, object.Namespace, string(object.UID)),
+		)
+		if err != nil {
+			reqLogger.Error(err, "failed to check organization ownership")
+			return err
+		}
+
+		if !owned && !hasCondition(object.Status.Conditions, reasonCreatedOrganization) {
+			reqLogger.Info("organization not owned by object, skipping deleting resources")
+			return nil
+		}
+
+		id = *organization.Id
 	}
 
-	if err := orgApi.DeleteOrganizationWithID(ctx, *organization.Id); err != nil {
+	if err := orgApi.DeleteOrganizationWithID(ctx, id); err != nil {
+		if isHttpStatusCode(err, 404) {
+			reqLogger.Info("organization not found")
+			return nil
+		}
 		reqLogger.Error(err, "failed to delete organization")
 		return err
 	}
@@ -157,6 +231,9 @@ func (r *OrganizationReconciler) FinalizeResources(ctx context.Context, clientOb
 			Conditions: append(object.Status.Conditions, condition),
 			Message:    "deleted influxdb organization",
 			Reason:     reasonDeletedOrganization,
+			ID:         object.Status.ID,
+			OrgID:      object.Status.OrgID,
+			ConfigName: object.Status.ConfigName,
 		}
 	}
 
@@ -271,84 +348,208 @@ func (r *OrganizationReconciler) ReconcileResources(ctx context.Context, clientO
 	}
 
 	// read config with influxdb info
-	config := &influxdbv1beta1.Config{}
-	if err := r.Get(ctx, types.NamespacedName{
-		Namespace: req.Namespace,
-		Name:      object.Spec.ConfigName,
-	}, config); err != nil {
+	config, err := getConfig(ctx, r.Client, req.Namespace, object.GetConfigRef())
+	if err != nil {
 		reqLogger.Error(err, "failed to read influxdb config")
 		return err
 	}
 
-	// read secret with influxdb token
-	secret := &v1.Secret{}
-	if err := r.Get(
-		ctx,
-		types.NamespacedName{
-			Namespace: config.Spec.TokenSecretNamespace,
-			Name:      config.Spec.TokenSecretName,
-		},
-		secret,
-	); err != nil {
-		reqLogger.Error(err, "failed to read influxdb token")
+	newClient, err := r.Clients.Get(ctx, r.Client, config)
+	if err != nil {
+		reqLogger.Error(err, "failed to create influxdb client")
 		return err
 	}
-
-	newClient := influxdb.NewClient(config.Spec.Addr, string(secret.Data[keyToken]))
 	// always close client at the end
 	defer newClient.Close()
 
 	orgApi := newClient.OrganizationsAPI()
 
-	organization, err := orgApi.FindOrganizationByName(ctx, config.Spec.OrgName)
+	organization, err := newClient.FindConfigOrganization(ctx, config)
 	if err != nil || organization == nil || organization.Id == nil {
 		reqLogger.Error(err, "failed to find influxdb org")
 		return err
 	}
 
 	var organizationCreated bool
+	var organizationAdopted bool
+	var organizationUpdated bool
 	var found bool
+	var org *domain.Organization
+
+	// influxdb label marking organizations owned by this object
+	ownerLabelName := getOwnerLabelName(object.Name, object.Namespace, string(object.UID))
+
+	// organization id recorded in status is the primary way of identifying the org,
+	// lookup by name is only a fallback for adopting orgs without a recorded id
+	if len(object.Status.ID) > 0 {
+		org, err = orgApi.FindOrganizationByID(ctx, object.Status.ID)
+		if err != nil {
+			if !isHttpStatusCode(err, 404) {
+				reqLogger.Error(err, "failed to find organization by id")
+				return err
+			}
+			reqLogger.Info("organization not found by id")
+			org = nil
+		}
+	}
 
-	if _, err := orgApi.CreateOrganization(
-		ctx,
-		&domain.Organization{
-			CreatedAt:   nil,
-			Description: nil,
-			Id:          nil,
-			Links:       nil,
-			Name:        object.Name,
-			Status:      nil,
-			UpdatedAt:   nil,
-		},
-	); err != nil {
-		httpErr := &http.Error{
-			StatusCode: 0,
-			Code:       "",
-			Message:    "",
-			Err:        nil,
-			RetryAfter: 0,
-		}
-		if errors.As(err, &httpErr) && httpErr.StatusCode == 422 {
-			rateLimit(
-				fmt.Sprintf("%s-%s", object.UID, "org"),
-				time.Hour*24,
-				func() {
-					reqLogger.Info("org exists")
-				},
-			)
+	if org == nil {
+		org, err = orgApi.FindOrganizationByName(ctx, object.GetOrgName())
+		if err != nil {
+			if !isHttpStatusCode(err, 404) {
+				reqLogger.Error(err, "failed to find organization")
+				return err
+			}
+			org = nil
+		}
+
+		// organization found by name is only taken over if it is already owned
+		// by this object or if adoption is explicitly requested
+		if org != nil && org.Id != nil {
+			owned, err := isOrganizationOwned(ctx, newClient, *org.Id, ownerLabelName)
+			if err != nil {
+				reqLogger.Error(err, "failed to check organization ownership")
+				return err
+			}
+
+			if !owned && hasCondition(object.Status.Conditions, reasonCreatedOrganization) {
+				if err := markOrganizationOwned(ctx, newClient, *org.Id, ownerLabelName); err != nil {
+					reqLogger.Error(err, "failed to mark organization as owned")
+					return err
+				}
+				owned = true
+			}
+
+			if !owned {
+				if !object.Spec.AdoptExisting {
+					message := "influxdb organization exists and is not owned by this object, set spec.adoptExisting to adopt it"
+					if object.Status.Phase != phaseConflict {
+						reqLogger.Info(message)
+						r.Recorder.Event(object, v1.EventTypeWarning, reasonConflictingOrganization, message)
+						object.Status.Phase = phaseConflict
+						object.Status.Message = message
+						object.Status.Reason = reasonConflictingOrganization
+						if err := r.Status().Update(ctx, object); err != nil {
+							reqLogger.Error(err, "failed to update object status")
+							return err
+						} else {
+							reqLogger.Info("updated object status")
+							return ObjectUpdated
+						}
+					}
+					return nil
+				}
+
+				if err := markOrganizationOwned(ctx, newClient, *org.Id, ownerLabelName); err != nil {
+					reqLogger.Error(err, "failed to mark organization as owned")
+					return err
+				}
+
+				reqLogger.Info("organization adopted")
+				r.Recorder.Event(object, v1.EventTypeNormal, reasonAdoptedOrganization, "adopted existing influxdb organization")
+				organizationAdopted = true
+			}
+		}
+	}
+
+	if org == nil {
+		if newOrg, err := orgApi.CreateOrganization(
+			ctx,
+			&domain.Organization{
+				CreatedAt:   nil,
+				Description: &object.Spec.Description,
+				Id:          nil,
+				Links:       nil,
+				Name:        object.GetOrgName(),
+				Status:      nil,
+				UpdatedAt:   nil,
+			},
+		); err != nil {
+			httpErr := &http.Error{
+				StatusCode: 0,
+				Code:       "",
+				Message:    "",
+				Err:        nil,
+				RetryAfter: 0,
+			}
+			if errors.As(err, &httpErr) && httpErr.StatusCode == 422 {
+				// organization was created concurrently by someone else and its
+				// ownership is checked on the next pass
+				reqLogger.Error(err, "org exists")
+				return err
+			} else {
+				reqLogger.Error(err, "failed to create organization")
+				return err
+			}
 		} else {
-			reqLogger.Error(err, "failed to create organization")
-			return err
+			reqLogger.Info("organization created")
+			organizationCreated = true
+			org = newOrg
+
+			if org != nil && org.Id != nil {
+				// organization id gets recorded in status regardless, so failing
+				// to label the organization does not affect ownership
+				if err := markOrganizationOwned(ctx, newClient, *org.Id, ownerLabelName); err != nil {
+					reqLogger.Error(err, "failed to mark organization as owned")
+				}
+			}
 		}
 	} else {
-		reqLogger.Info("organization created")
-		organizationCreated = true
+		rateLimit(
+			fmt.Sprintf("%s-%s", object.UID, "org"),
+			time.Hour*24,
+			func() {
+				reqLogger.Info("org exists")
+			},
+		)
+
+		// name and description are mutable on influxdb side,
+		// so bring them back in sync with the spec if they have drifted
+		if org.Name != object.GetOrgName() ||
+			getOrganizationDescription(org) != object.Spec.Description {
+			org.Name = object.GetOrgName()
+			org.Description = &object.Spec.Description
+
+			if _, err := orgApi.UpdateOrganization(ctx, org); err != nil {
+				reqLogger.Error(err, "failed to update organization")
+				return err
+			}
+
+			reqLogger.Info("organization updated")
+			organizationUpdated = true
+		}
+	}
+
+	// members and owners are reconciled on every pass to undo changes made
+	// on influxdb side
+	if org != nil && org.Id != nil {
+		membersChanged, err := reconcileOrganizationMembers(ctx, r.Client, object, newClient, *org.Id)
+		if err != nil {
+			reqLogger.Error(err, "failed to reconcile organization members")
+			if errors.Is(err, UserNotFound) {
+				r.Recorder.Event(object, v1.EventTypeWarning, reasonWaitingForUser, err.Error())
+			}
+			return err
+		}
+
+		if membersChanged {
+			reqLogger.Info("organization members updated")
+			organizationUpdated = true
+		}
+	}
+
+	// record influxdb ids so that subsequent lookups are direct
+	var idChanged bool
+	if org != nil && org.Id != nil && (object.Status.ID != *org.Id || object.Status.OrgID != *org.Id) {
+		object.Status.ID = *org.Id
+		object.Status.OrgID = *org.Id
+		idChanged = true
 	}
 
 	// Update the status of the object if pending
 	for i, condition := range object.Status.Conditions {
-		if condition.Reason == reasonCreatedOrganization {
-			if organizationCreated {
+		if condition.Reason == reasonCreatedOrganization || condition.Reason == reasonAdoptedOrganization {
+			if organizationCreated || organizationAdopted {
 				object.Status.Conditions[i].LastTransitionTime = v12.Time{Time: time.Now()}
 			}
 			found = true
@@ -356,19 +557,26 @@ func (r *OrganizationReconciler) ReconcileResources(ctx context.Context, clientO
 		}
 	}
 	if !found {
+		reason, message := reasonCreatedOrganization, "created influxdb organization"
+		if organizationAdopted {
+			reason, message = reasonAdoptedOrganization, "adopted influxdb organization"
+		}
 		condition := v12.Condition{
 			Type:               conditionTypeInfluxdb,
 			Status:             v12.ConditionTrue,
 			ObservedGeneration: 0,
 			LastTransitionTime: v12.Time{Time: time.Now()},
-			Reason:             reasonCreatedOrganization,
-			Message:            "created influxdb organization",
+			Reason:             reason,
+			Message:            message,
 		}
 		object.Status = influxdbv1beta1.OrganizationStatus{
 			Phase:      phaseReady,
 			Conditions: append(object.Status.Conditions, condition),
-			Message:    "created influxdb organization",
-			Reason:     reasonCreatedOrganization,
+			Message:    message,
+			Reason:     reason,
+			ID:         object.Status.ID,
+			OrgID:      object.Status.OrgID,
+			ConfigName: object.Status.ConfigName,
 		}
 		if err := r.Status().Update(ctx, object); err != nil {
 			reqLogger.Error(err, "failed to update object status")
@@ -378,7 +586,43 @@ func (r *OrganizationReconciler) ReconcileResources(ctx context.Context, clientO
 			return ObjectUpdated
 		}
 	} else {
-		if organizationCreated {
+		if organizationUpdated {
+			found = false
+			for i, condition := range object.Status.Conditions {
+				if condition.Reason == reasonUpdatedOrganization {
+					object.Status.Conditions[i].LastTransitionTime = v12.Time{Time: time.Now()}
+					found = true
+					break
+				}
+			}
+			if !found {
+				object.Status.Conditions = append(object.Status.Conditions, v12.Condition{
+					Type:               conditionTypeInfluxdb,
+					Status:             v12.ConditionTrue,
+					ObservedGeneration: 0,
+					LastTransitionTime: v12.Time{Time: time.Now()},
+					Reason:             reasonUpdatedOrganization,
+					Message:            "updated influxdb organization",
+				})
+			}
+			object.Status.Phase = phaseReady
+			object.Status.Message = "updated influxdb organization"
+			object.Status.Reason = reasonUpdatedOrganization
+		}
+
+		// conflict is resolved once the organization is owned by this object
+		var conflictResolved bool
+		if object.Status.Phase == phaseConflict {
+			object.Status.Phase = phaseReady
+			object.Status.Message = "resolved influxdb organization conflict"
+			object.Status.Reason = reasonCreatedOrganization
+			if organizationAdopted {
+				object.Status.Reason = reasonAdoptedOrganization
+			}
+			conflictResolved = true
+		}
+
+		if organizationCreated || organizationAdopted || organizationUpdated || idChanged || conflictResolved {
 			if err := r.Status().Update(ctx, object); err != nil {
 				reqLogger.Error(err, "failed to update object status")
 				return err
@@ -389,5 +633,64 @@ func (r *OrganizationReconciler) ReconcileResources(ctx context.Context, clientO
 		}
 	}
 
+	// config generated from the template points at the organization,
+	// hence it is only reconciled once the organization is ready
+	if object.Spec.ConfigTemplate != nil && len(object.Status.OrgID) > 0 {
+		configChanged, err := reconcileGeneratedConfig(ctx, r.Client, r.Scheme, object, config)
+		if err != nil {
+			reqLogger.Error(err, "failed to reconcile generated config")
+			if errors.Is(err, ResourceNotOwned) || errors.Is(err, ConfigNotAllowed) {
+				r.Recorder.Event(object, v1.EventTypeWarning, reasonConflictingConfig, err.Error())
+			}
+			return err
+		}
+
+		if configChanged {
+			reqLogger.Info("generated config updated")
+			r.Recorder.Event(object, v1.EventTypeNormal, reasonGeneratedConfig,
+				fmt.Sprintf("generated config %s", object.GetGeneratedConfigName()))
+		}
+	}
+
+	// generated objects the template no longer asks for are removed per deletion policy
+	removed, err := pruneGeneratedObjects(ctx, r.Client, object,
+		getDeletionPolicy(object.Spec.DeletionPolicy, r.DefaultDeletionPolicy))
+	if err != nil {
+		reqLogger.Error(err, "failed to remove generated objects")
+		return err
+	}
+
+	if len(removed) > 0 {
+		reqLogger.Info("generated objects removed", "objects", removed)
+		r.Recorder.Event(object, v1.EventTypeNormal, reasonRemovedGeneratedConfig,
+			fmt.Sprintf("removed generated %s", strings.Join(removed, ", ")))
+	}
+
+	// name of generated config is cleared once the template is removed
+	var configName string
+	if len(object.Status.OrgID) > 0 {
+		configName = object.GetGeneratedConfigName()
+	}
+
+	if object.Status.ConfigName != configName {
+		object.Status.ConfigName = configName
+		if err := r.Status().Update(ctx, object); err != nil {
+			reqLogger.Error(err, "failed to update object status")
+			return err
+		} else {
+			reqLogger.Info("updated object status")
+			return ObjectUpdated
+		}
+	}
+
 	return nil
 }
+
+// getOrganizationDescription returns organization description treating nil as empty
+func getOrganizationDescription(org *domain.Organization) string {
+	if org.Description == nil {
+		return ""
+	}
+
+	return *org.Description
+}
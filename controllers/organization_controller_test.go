@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newOrganizationTest returns organization reconciler and an organization
+// managed via config of the bootstrap org of fake influxdb
+func newOrganizationTest(
+	t *testing.T,
+	server *fakeInfluxdb,
+	objects ...client.Object,
+) (*OrganizationReconciler, *influxdbv1beta1.Organization) {
+	server.CreateOrg("influxdata")
+
+	object := &influxdbv1beta1.Organization{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "team", UID: "team-uid"},
+		Spec: influxdbv1beta1.OrganizationSpec{
+			ConfigName: "admin",
+		},
+	}
+
+	objects = append(objects, server.Config("team", "admin", "influxdata"), server.TokenSecret("team"), object)
+	r := &OrganizationReconciler{
+		Client:                newFakeClient(objects...),
+		Scheme:                scheme.Scheme,
+		Recorder:              record.NewFakeRecorder(100),
+		Clients:               NewClientPool(),
+		DefaultDeletionPolicy: influxdbv1beta1.DeletionPolicyDelete,
+	}
+
+	return r, object
+}
+
+// getOrganization returns current state of the organization
+func getOrganization(
+	t *testing.T,
+	r *OrganizationReconciler,
+	object *influxdbv1beta1.Organization,
+) *influxdbv1beta1.Organization {
+	current := getObject(t, r.Client, object)
+	if current == nil {
+		t.Fatalf("organization not found")
+	}
+
+	return current.(*influxdbv1beta1.Organization)
+}
+
+func TestOrganizationReconcileAdoption(t *testing.T) {
+	tests := []struct {
+		name          string
+		adoptExisting bool
+		wantPhase     string
+		wantOwned     bool
+	}{
+		{
+			name:      "conflict",
+			wantPhase: phaseConflict,
+		},
+		{
+			name:          "adopted",
+			adoptExisting: true,
+			wantPhase:     phaseReady,
+			wantOwned:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := newFakeInfluxdb(t)
+			orgId := server.CreateOrg("team")
+			r, object := newOrganizationTest(t, server)
+			object.Spec.AdoptExisting = tt.adoptExisting
+			if err := r.Update(ctx, object); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			object = getOrganization(t, r, object)
+			if object.Status.Phase != tt.wantPhase {
+				t.Errorf("phase = %s, want %s", object.Status.Phase, tt.wantPhase)
+			}
+			if owned := object.Status.ID == orgId; owned != tt.wantOwned {
+				t.Errorf("status id = %q, want owned %v", object.Status.ID, tt.wantOwned)
+			}
+
+			var wantLabels []string
+			if tt.wantOwned {
+				wantLabels = []string{getOwnerLabelName(object.Name, object.Namespace, string(object.UID))}
+			}
+			if got := server.LabelNames(orgId); !reflect.DeepEqual(got, wantLabels) {
+				t.Errorf("labels = %v, want %v", got, wantLabels)
+			}
+
+			// organizations that were not adopted are never deleted
+			if err := r.Delete(ctx, object); err != nil {
+				t.Fatal(err)
+			}
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			if deleted := server.Org(orgId) == nil; deleted != tt.wantOwned {
+				t.Errorf("organization deleted = %v, want %v", deleted, tt.wantOwned)
+			}
+		})
+	}
+}
+
+func TestOrganizationReconcileMembers(t *testing.T) {
+	tests := []struct {
+		name         string
+		pruneMembers bool
+		wantMembers  []string
+	}{
+		{
+			name:        "kept",
+			wantMembers: []string{"admin", "alice", "bob", "carol"},
+		},
+		{
+			name:         "pruned",
+			pruneMembers: true,
+			wantMembers:  []string{"admin", "alice", "carol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := newFakeInfluxdb(t)
+			server.CreateUser("alice")
+			bobId := server.CreateUser("bob")
+			carolId := server.CreateUser("carol")
+
+			// carol holds a role in the org granted by a User object
+			carol := &influxdbv1beta1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "carol", Namespace: "team"},
+			}
+			r, object := newOrganizationTest(t, server, carol)
+
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			object = getOrganization(t, r, object)
+			orgId := object.Status.ID
+			for _, userId := range []string{fakeAdminId, bobId, carolId} {
+				server.AddMember(orgId, userId)
+			}
+
+			carol.Status = influxdbv1beta1.UserStatus{
+				ID: carolId,
+				Memberships: []influxdbv1beta1.UserMembershipStatus{
+					{OrgID: orgId, Role: influxdbv1beta1.UserRoleMember},
+				},
+			}
+			if err := r.Status().Update(ctx, carol); err != nil {
+				t.Fatal(err)
+			}
+
+			object.Spec.Members = []influxdbv1beta1.OrganizationMember{{Name: "alice"}}
+			object.Spec.PruneMembers = tt.pruneMembers
+			if err := r.Update(ctx, object); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			var members []string
+			for _, userId := range server.Members(orgId, domain.ResourceMemberRoleMember) {
+				members = append(members, server.User(userId).Name)
+			}
+			sort.Strings(members)
+			if !reflect.DeepEqual(members, tt.wantMembers) {
+				t.Errorf("members = %v, want %v", members, tt.wantMembers)
+			}
+		})
+	}
+}
+
+func TestOrganizationReconcileConfigTemplate(t *testing.T) {
+	tests := []struct {
+		name           string
+		deletionPolicy string
+		wantOwned      bool
+	}{
+		{
+			name:           "deleted",
+			deletionPolicy: influxdbv1beta1.DeletionPolicyDelete,
+			wantOwned:      true,
+		},
+		{
+			name:           "orphaned",
+			deletionPolicy: influxdbv1beta1.DeletionPolicyOrphan,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server := newFakeInfluxdb(t)
+			r, object := newOrganizationTest(t, server)
+
+			object.Spec.DeletionPolicy = tt.deletionPolicy
+			object.Spec.ConfigTemplate = &influxdbv1beta1.OrganizationConfigTemplate{
+				AdminToken: &influxdbv1beta1.OrganizationAdminToken{},
+			}
+			if err := r.Update(ctx, object); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			object = getOrganization(t, r, object)
+			generated := []client.Object{
+				&influxdbv1beta1.Config{ObjectMeta: metav1.ObjectMeta{
+					Name: object.GetGeneratedConfigName(), Namespace: object.Namespace}},
+				&influxdbv1beta1.Config{ObjectMeta: metav1.ObjectMeta{
+					Name: object.GetAdminConfigName(), Namespace: object.Namespace}},
+				&influxdbv1beta1.Token{ObjectMeta: metav1.ObjectMeta{
+					Name: object.GetAdminTokenSecretName(), Namespace: object.Namespace}},
+			}
+
+			config, ok := getObject(t, r.Client, generated[0]).(*influxdbv1beta1.Config)
+			if !ok {
+				t.Fatalf("config %s not generated", generated[0].GetName())
+			}
+			if config.Spec.OrgName != "team" || config.Spec.TokenSecretName != object.GetAdminTokenSecretName() {
+				t.Errorf("config spec = %+v, want org team with admin token", config.Spec)
+			}
+
+			token, ok := getObject(t, r.Client, generated[2]).(*influxdbv1beta1.Token)
+			if !ok {
+				t.Fatalf("token %s not generated", generated[2].GetName())
+			}
+			if token.Spec.ConfigName != object.GetAdminConfigName() || token.Spec.DeletionPolicy != tt.deletionPolicy {
+				t.Errorf("token spec = %+v, want admin config and deletion policy %s", token.Spec, tt.deletionPolicy)
+			}
+
+			if err := r.Delete(ctx, object); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			for _, generatedObject := range generated {
+				current := getObject(t, r.Client, generatedObject)
+				if current == nil {
+					t.Fatalf("%s not found", generatedObject.GetName())
+				}
+
+				if owned := metav1.IsControlledBy(current, object); owned != tt.wantOwned {
+					t.Errorf("%s owned = %v, want %v", current.GetName(), owned, tt.wantOwned)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	influxdb "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// influxdb resources created or adopted by the operator are marked with an
+// influxdb label unique to the owning object. buckets carry the label directly,
+// whereas organizations cannot be labeled and instead contain the label.
+
+// getOwnerLabelName returns influxdb label name that marks resources owned by the object
+func getOwnerLabelName(name, namespace, uid string) string {
+	return fmt.Sprintf("%s.%s.%s", name, namespace, uid)
+}
+
+// findOwnerLabel finds owner label in the org returning nil if none exists
+func findOwnerLabel(ctx context.Context, newClient influxdb.Client, orgId, labelName string) (*domain.Label, error) {
+	labels, err := newClient.LabelsAPI().FindLabelsByOrgID(ctx, orgId)
+	if err != nil {
+		return nil, err
+	}
+
+	if labels == nil {
+		return nil, fmt.Errorf("received nil labels")
+	}
+
+	for i := range *labels {
+		if (*labels)[i].Name != nil && *(*labels)[i].Name == labelName {
+			return &(*labels)[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ensureOwnerLabel finds or creates owner label in the org
+func ensureOwnerLabel(ctx context.Context, newClient influxdb.Client, orgId, labelName string) (*domain.Label, error) {
+	label, err := findOwnerLabel(ctx, newClient, orgId, labelName)
+	if err != nil {
+		return nil, err
+	}
+
+	if label != nil {
+		return label, nil
+	}
+
+	label, err = newClient.LabelsAPI().CreateLabelWithNameWithID(
+		ctx,
+		orgId,
+		labelName,
+		map[string]string{
+			labelPropertyManagedBy: managedBy,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if label == nil || label.Id == nil {
+		return nil, fmt.Errorf("received nil label or label id")
+	}
+
+	return label, nil
+}
+
+// deleteOwnerLabel deletes owner label from the org, which also detaches it
+// from resources carrying it
+func deleteOwnerLabel(ctx context.Context, newClient influxdb.Client, orgId, labelName string) error {
+	label, err := findOwnerLabel(ctx, newClient, orgId, labelName)
+	if err != nil {
+		return err
+	}
+
+	if label == nil || label.Id == nil {
+		return nil
+	}
+
+	if err := newClient.LabelsAPI().DeleteLabelWithID(ctx, *label.Id); err != nil && !isHttpStatusCode(err, 404) {
+		return err
+	}
+
+	return nil
+}
+
+// isBucketOwned checks if bucket carries the owner label
+func isBucketOwned(ctx context.Context, newClient influxdb.Client, bucketId, labelName string) (bool, error) {
+	response, err := domain.NewClientWithResponses(newClient.HTTPService()).
+		GetBucketsIDLabelsWithResponse(ctx, bucketId, &domain.GetBucketsIDLabelsParams{})
+	if err != nil {
+		return false, err
+	}
+
+	if response.JSONDefault != nil {
+		return false, domain.ErrorToHTTPError(response.JSONDefault, response.StatusCode())
+	}
+
+	if response.JSON200 == nil || response.JSON200.Labels == nil {
+		return false, nil
+	}
+
+	for _, label := range *response.JSON200.Labels {
+		if label.Name != nil && *label.Name == labelName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// markBucketOwned attaches owner label to the bucket
+func markBucketOwned(ctx context.Context, newClient influxdb.Client, orgId, bucketId, labelName string) error {
+	label, err := ensureOwnerLabel(ctx, newClient, orgId, labelName)
+	if err != nil {
+		return err
+	}
+
+	response, err := domain.NewClientWithResponses(newClient.HTTPService()).
+		PostBucketsIDLabelsWithResponse(
+			ctx,
+			bucketId,
+			&domain.PostBucketsIDLabelsParams{},
+			domain.PostBucketsIDLabelsJSONRequestBody{
+				LabelID: label.Id,
+			},
+		)
+	if err != nil {
+		return err
+	}
+
+	// label already attached to the bucket is reported as conflict
+	if response.JSONDefault != nil && response.StatusCode() != 409 {
+		return domain.ErrorToHTTPError(response.JSONDefault, response.StatusCode())
+	}
+
+	return nil
+}
+
+// isOrganizationOwned checks if org contains the owner label
+func isOrganizationOwned(ctx context.Context, newClient influxdb.Client, orgId, labelName string) (bool, error) {
+	label, err := findOwnerLabel(ctx, newClient, orgId, labelName)
+	if err != nil {
+		return false, err
+	}
+
+	return label != nil, nil
+}
+
+// markOrganizationOwned creates owner label in the org
+func markOrganizationOwned(ctx context.Context, newClient influxdb.Client, orgId, labelName string) error {
+	_, err := ensureOwnerLabel(ctx, newClient, orgId, labelName)
+	return err
+}
+
+// hasCondition checks if conditions contain a condition with matching reason.
+// Objects that recorded creating influxdb resources prior to ownership labels
+// being introduced are identified this way
+func hasCondition(conditions []v12.Condition, reason string) bool {
+	for _, condition := range conditions {
+		if condition.Reason == reason {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// getPermissionBuckets resolves influxdb buckets that permissions are scoped to,
+// either via Bucket object reference or via bucket name. Returned buckets are
+// in the order of permissions with nil entries for permissions not scoped to a bucket.
+// Referenced Bucket objects need to be ready before their bucket can be resolved
+func getPermissionBuckets(
+	ctx context.Context,
+	c client.Client,
+	bucketsApi api.BucketsAPI,
+	namespace string,
+	orgId string,
+	specPermissions []influxdbv1beta1.Permission,
+) ([]*domain.Bucket, error) {
+	buckets := make([]*domain.Bucket, len(specPermissions))
+	for i, permission := range specPermissions {
+		if permission.ResourceType != influxdbv1beta1.ResourceTypeBuckets {
+			continue
+		}
+
+		if permission.BucketRef != nil {
+			object := &influxdbv1beta1.Bucket{}
+			if err := c.Get(ctx, types.NamespacedName{
+				Namespace: namespace,
+				Name:      permission.BucketRef.Name,
+			}, object); err != nil {
+				return nil, fmt.Errorf("failed to get referenced bucket %s: %w", permission.BucketRef.Name, err)
+			}
+
+			if object.GetDeletionTimestamp() != nil ||
+				object.Status.Phase != phaseReady ||
+				len(object.Status.ID) == 0 {
+				return nil, fmt.Errorf("referenced bucket %s is not ready", permission.BucketRef.Name)
+			}
+
+			if object.Status.OrgID != orgId {
+				return nil, fmt.Errorf("referenced bucket %s belongs to a different org", permission.BucketRef.Name)
+			}
+
+			bucket, err := bucketsApi.FindBucketByID(ctx, object.Status.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find referenced bucket %s: %w", permission.BucketRef.Name, err)
+			}
+
+			if bucket == nil || bucket.Id == nil {
+				return nil, fmt.Errorf("received nil bucket or bucket id")
+			}
+
+			buckets[i] = bucket
+			continue
+		}
+
+		if len(permission.ResourceName) > 0 {
+			bucket, err := findBucketByName(ctx, bucketsApi, orgId, permission.ResourceName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find bucket %s: %w", permission.ResourceName, err)
+			}
+
+			if bucket == nil || bucket.Id == nil {
+				return nil, fmt.Errorf("bucket %s not found", permission.ResourceName)
+			}
+
+			buckets[i] = bucket
+		}
+	}
+
+	return buckets, nil
+}
+
+// getPermissions converts permissions defined in the spec to influxdb permissions
+// scoped to the org. Permissions with a resolved bucket are scoped to that bucket
+func getPermissions(
+	specPermissions []influxdbv1beta1.Permission,
+	organization *domain.Organization,
+	buckets []*domain.Bucket,
+) []domain.Permission {
+	permissions := make([]domain.Permission, len(specPermissions))
+	for i, permission := range specPermissions {
+		permission := permission
+		name := new(string)
+		if len(permission.ResourceName) > 0 {
+			*name = permission.ResourceName
+		}
+		if i < len(buckets) && buckets[i] != nil {
+			*name = buckets[i].Name
+			permissions[i] = domain.Permission{
+				Action: domain.PermissionAction(permission.PermissionType),
+				Resource: domain.Resource{
+					Id:    buckets[i].Id,
+					Name:  name,
+					Org:   &organization.Name,
+					OrgID: organization.Id,
+					Type:  domain.ResourceType(permission.ResourceType),
+				},
+			}
+		} else if permission.ResourceType == influxdbv1beta1.ResourceTypeOrgs {
+			// orgs permission naming the org of the token is scoped to it,
+			// otherwise it applies to all orgs
+			var id *string
+			if len(permission.ResourceName) > 0 && permission.ResourceName == organization.Name {
+				id = organization.Id
+			}
+			permissions[i] = domain.Permission{
+				Action: domain.PermissionAction(permission.PermissionType),
+				Resource: domain.Resource{
+					Id:    id,
+					Name:  name,
+					Org:   nil,
+					OrgID: nil,
+					Type:  domain.ResourceType(permission.ResourceType),
+				},
+			}
+		} else {
+			permissions[i] = domain.Permission{
+				Action: domain.PermissionAction(permission.PermissionType),
+				Resource: domain.Resource{
+					Id:    nil,
+					Name:  name,
+					Org:   &organization.Name,
+					OrgID: organization.Id,
+					Type:  domain.ResourceType(permission.ResourceType),
+				},
+			}
+		}
+	}
+
+	return permissions
+}
+
+// equalPermissions checks if authorization permissions match desired permissions.
+// Resource names are not compared since influxdb derives them from resource ids
+func equalPermissions(current *[]domain.Permission, desired []domain.Permission) bool {
+	if current == nil {
+		return len(desired) == 0
+	}
+
+	if len(*current) != len(desired) {
+		return false
+	}
+
+	currentKeys := make([]string, len(*current))
+	for i := range *current {
+		currentKeys[i] = getPermissionKey((*current)[i])
+	}
+
+	desiredKeys := make([]string, len(desired))
+	for i := range desired {
+		desiredKeys[i] = getPermissionKey(desired[i])
+	}
+
+	sort.Strings(currentKeys)
+	sort.Strings(desiredKeys)
+
+	for i := range currentKeys {
+		if currentKeys[i] != desiredKeys[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getPermissionKey returns a string identifying permission action and resource.
+// Bucket permissions are keyed by resolved bucket id, hence tokens issued before
+// names were resolved, which hold no bucket id, are replaced once after upgrade.
+// Such tokens grant access to all buckets since influxdb ignores names without ids
+func getPermissionKey(permission domain.Permission) string {
+	var id, orgId string
+	if permission.Resource.Id != nil {
+		id = *permission.Resource.Id
+	}
+	if permission.Resource.OrgID != nil {
+		orgId = *permission.Resource.OrgID
+	}
+
+	return fmt.Sprintf("%s/%s/%s/%s", permission.Action, permission.Resource.Type, orgId, id)
+}
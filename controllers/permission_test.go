@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+)
+
+func TestGetPermissions(t *testing.T) {
+	orgId, orgName := "org-id", "org"
+	bucketId, bucketName := "bucket-id", "bucket"
+	organization := &domain.Organization{Id: &orgId, Name: orgName}
+	bucket := &domain.Bucket{Id: &bucketId, Name: bucketName}
+
+	tests := []struct {
+		name       string
+		permission influxdbv1beta1.Permission
+		bucket     *domain.Bucket
+		wantId     *string
+		wantOrgId  *string
+		wantName   string
+	}{
+		{
+			name: "orgs",
+			permission: influxdbv1beta1.Permission{
+				ResourceType:   influxdbv1beta1.ResourceTypeOrgs,
+				PermissionType: influxdbv1beta1.PermissionRead,
+			},
+		},
+		{
+			name: "orgs naming token org",
+			permission: influxdbv1beta1.Permission{
+				ResourceName:   orgName,
+				ResourceType:   influxdbv1beta1.ResourceTypeOrgs,
+				PermissionType: influxdbv1beta1.PermissionWrite,
+			},
+			wantId:   &orgId,
+			wantName: orgName,
+		},
+		{
+			name: "orgs naming other org",
+			permission: influxdbv1beta1.Permission{
+				ResourceName:   "other-org",
+				ResourceType:   influxdbv1beta1.ResourceTypeOrgs,
+				PermissionType: influxdbv1beta1.PermissionRead,
+			},
+			wantName: "other-org",
+		},
+		{
+			name: "bucket id",
+			permission: influxdbv1beta1.Permission{
+				ResourceType:   influxdbv1beta1.ResourceTypeBuckets,
+				PermissionType: influxdbv1beta1.PermissionWrite,
+				BucketRef:      &influxdbv1beta1.BucketReference{Name: "object"},
+			},
+			bucket:    bucket,
+			wantId:    &bucketId,
+			wantOrgId: &orgId,
+			wantName:  bucketName,
+		},
+		{
+			name: "bucket name",
+			permission: influxdbv1beta1.Permission{
+				ResourceName:   bucketName,
+				ResourceType:   influxdbv1beta1.ResourceTypeBuckets,
+				PermissionType: influxdbv1beta1.PermissionRead,
+			},
+			bucket:    bucket,
+			wantId:    &bucketId,
+			wantOrgId: &orgId,
+			wantName:  bucketName,
+		},
+		{
+			name: "name only",
+			permission: influxdbv1beta1.Permission{
+				ResourceName:   "dashboard",
+				ResourceType:   "dashboards",
+				PermissionType: influxdbv1beta1.PermissionRead,
+			},
+			wantOrgId: &orgId,
+			wantName:  "dashboard",
+		},
+		{
+			name: "all of type",
+			permission: influxdbv1beta1.Permission{
+				ResourceType:   influxdbv1beta1.ResourceTypeBuckets,
+				PermissionType: influxdbv1beta1.PermissionRead,
+			},
+			wantOrgId: &orgId,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permissions := getPermissions(
+				[]influxdbv1beta1.Permission{tt.permission},
+				organization,
+				[]*domain.Bucket{tt.bucket},
+			)
+			if len(permissions) != 1 {
+				t.Fatalf("got %d permissions, want 1", len(permissions))
+			}
+
+			resource := permissions[0].Resource
+			if string(permissions[0].Action) != tt.permission.PermissionType {
+				t.Errorf("action = %s, want %s", permissions[0].Action, tt.permission.PermissionType)
+			}
+			if string(resource.Type) != tt.permission.ResourceType {
+				t.Errorf("type = %s, want %s", resource.Type, tt.permission.ResourceType)
+			}
+			if got, want := stringValue(resource.Id), stringValue(tt.wantId); got != want {
+				t.Errorf("id = %q, want %q", got, want)
+			}
+			if got, want := stringValue(resource.OrgID), stringValue(tt.wantOrgId); got != want {
+				t.Errorf("org id = %q, want %q", got, want)
+			}
+			if got := stringValue(resource.Name); got != tt.wantName {
+				t.Errorf("name = %q, want %q", got, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestEqualPermissions(t *testing.T) {
+	orgId, otherOrgId := "org-id", "other-org-id"
+	bucketId, otherBucketId := "bucket-id", "other-bucket-id"
+	bucketName := "bucket"
+
+	bucketRead := domain.Permission{
+		Action: domain.PermissionActionRead,
+		Resource: domain.Resource{
+			Id:    &bucketId,
+			OrgID: &orgId,
+			Type:  domain.ResourceTypeBuckets,
+		},
+	}
+	bucketReadNamed := bucketRead
+	bucketReadNamed.Resource.Name = &bucketName
+	otherBucketRead := bucketRead
+	otherBucketRead.Resource.Id = &otherBucketId
+	bucketWrite := bucketRead
+	bucketWrite.Action = domain.PermissionActionWrite
+	orgsRead := domain.Permission{
+		Action:   domain.PermissionActionRead,
+		Resource: domain.Resource{Type: domain.ResourceTypeOrgs},
+	}
+	scopedOrgsRead := orgsRead
+	scopedOrgsRead.Resource.Id = &orgId
+	otherOrgBucketRead := bucketRead
+	otherOrgBucketRead.Resource.OrgID = &otherOrgId
+
+	tests := []struct {
+		name    string
+		current *[]domain.Permission
+		desired []domain.Permission
+		want    bool
+	}{
+		{
+			name: "nil and empty",
+			want: true,
+		},
+		{
+			name:    "nil and non empty",
+			desired: []domain.Permission{bucketRead},
+			want:    false,
+		},
+		{
+			name:    "same order",
+			current: &[]domain.Permission{bucketRead, bucketWrite},
+			desired: []domain.Permission{bucketRead, bucketWrite},
+			want:    true,
+		},
+		{
+			name:    "different order",
+			current: &[]domain.Permission{bucketWrite, orgsRead},
+			desired: []domain.Permission{orgsRead, bucketWrite},
+			want:    true,
+		},
+		{
+			name:    "names ignored",
+			current: &[]domain.Permission{bucketReadNamed},
+			desired: []domain.Permission{bucketRead},
+			want:    true,
+		},
+		{
+			name:    "different action",
+			current: &[]domain.Permission{bucketRead},
+			desired: []domain.Permission{bucketWrite},
+			want:    false,
+		},
+		{
+			name:    "different bucket id",
+			current: &[]domain.Permission{bucketRead},
+			desired: []domain.Permission{otherBucketRead},
+			want:    false,
+		},
+		{
+			name:    "different org id",
+			current: &[]domain.Permission{bucketRead},
+			desired: []domain.Permission{otherOrgBucketRead},
+			want:    false,
+		},
+		{
+			name:    "orgs scoped and unscoped",
+			current: &[]domain.Permission{orgsRead},
+			desired: []domain.Permission{scopedOrgsRead},
+			want:    false,
+		},
+		{
+			name:    "different length",
+			current: &[]domain.Permission{bucketRead},
+			desired: []domain.Permission{bucketRead, bucketWrite},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := equalPermissions(tt.current, tt.desired); got != tt.want {
+				t.Errorf("equalPermissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
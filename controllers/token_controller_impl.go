@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"time"
 
 	influxdb "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
 	"github.com/influxdata/influxdb-client-go/v2/api/http"
 	"github.com/influxdata/influxdb-client-go/v2/domain"
 	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
@@ -37,10 +39,16 @@ func (r *TokenReconciler) FinalizeStatus(ctx context.Context, clientObject clien
 	// Update the status of the object if not terminating
 	if object.Status.Phase != phaseTerminating {
 		object.Status = influxdbv1beta1.TokenStatus{
-			Phase:      phaseTerminating,
-			Conditions: object.Status.Conditions,
-			Message:    "object is marked for deletion",
-			Reason:     reasonObjectMarkedForDeletion,
+			Phase:            phaseTerminating,
+			Conditions:       object.Status.Conditions,
+			Message:          "object is marked for deletion",
+			Reason:           reasonObjectMarkedForDeletion,
+			Data:             object.Status.Data,
+			ID:               object.Status.ID,
+			OrgID:            object.Status.OrgID,
+			PreviousTokens:   object.Status.PreviousTokens,
+			LastRotationTime: object.Status.LastRotationTime,
+			NextRotationTime: object.Status.NextRotationTime,
 		}
 		if err := r.Status().Update(ctx, object); err != nil {
 			reqLogger.Error(err, "failed to update object status")
@@ -68,8 +76,83 @@ func (r *TokenReconciler) FinalizeResources(ctx context.Context, clientObject cl
 		return err
 	}
 
+	if policy := getDeletionPolicy(object.Spec.DeletionPolicy, r.DefaultDeletionPolicy); policy != influxdbv1beta1.DeletionPolicyDelete {
+		for _, condition := range object.Status.Conditions {
+			if condition.Reason == reasonRetainedToken {
+				return nil
+			}
+		}
+
+		// orphan releases token secret from garbage collection so that
+		// workloads using it continue to work
+		if policy == influxdbv1beta1.DeletionPolicyOrphan {
+			secret := &v1.Secret{}
+			if err := r.Get(ctx, types.NamespacedName{
+				Namespace: object.Namespace,
+				Name:      object.Spec.SecretName,
+			}, secret); err != nil {
+				if !apimachineryerrors.IsNotFound(err) {
+					reqLogger.Error(err, "failed to get secret")
+					return err
+				}
+			} else {
+				var ownerReferences []v12.OwnerReference
+				for _, ownerReference := range secret.OwnerReferences {
+					if ownerReference.UID != object.UID {
+						ownerReferences = append(ownerReferences, ownerReference)
+					}
+				}
+
+				if len(ownerReferences) != len(secret.OwnerReferences) {
+					secret.OwnerReferences = ownerReferences
+					if err := r.Update(ctx, secret); err != nil {
+						reqLogger.Error(err, "failed to release secret")
+						return err
+					}
+					reqLogger.Info("released secret")
+				}
+			}
+		}
+
+		message := fmt.Sprintf("retained influxdb token per %s deletion policy", policy)
+		reqLogger.Info(message)
+		r.Recorder.Event(object, v1.EventTypeNormal, reasonRetainedToken, message)
+
+		condition := v12.Condition{
+			Type:               conditionTypeInfluxdb,
+			Status:             v12.ConditionTrue,
+			ObservedGeneration: 0,
+			LastTransitionTime: v12.Time{Time: time.Now()},
+			Reason:             reasonRetainedToken,
+			Message:            message,
+		}
+		object.Status = influxdbv1beta1.TokenStatus{
+			Phase:            object.Status.Phase,
+			Conditions:       append(object.Status.Conditions, condition),
+			Message:          message,
+			Reason:           reasonRetainedToken,
+			Data:             object.Status.Data,
+			ID:               object.Status.ID,
+			OrgID:            object.Status.OrgID,
+			PreviousTokens:   object.Status.PreviousTokens,
+			LastRotationTime: object.Status.LastRotationTime,
+			NextRotationTime: object.Status.NextRotationTime,
+		}
+
+		if err := r.Status().Update(ctx, object); err != nil {
+			reqLogger.Error(err, "failed to update object status")
+			return err
+		} else {
+			reqLogger.Info("updated object status")
+			return ObjectUpdated
+		}
+	}
+
 	var tokenId string
-	if object.Status.Data != nil {
+	// authorization id recorded in status is the primary way of identifying
+	// the token with id recorded in status data kept for older objects
+	tokenId = object.Status.ID
+	if len(tokenId) == 0 && object.Status.Data != nil {
 		if id, ok := object.Status.Data[keyTokenId]; ok {
 			tokenId = id
 		}
@@ -79,100 +162,103 @@ func (r *TokenReconciler) FinalizeResources(ctx context.Context, clientObject cl
 	authorizationDescription := getAuthorizationDescription(object.Name, object.Namespace, string(object.UID))
 
 	// read config with influxdb info
-	config := &influxdbv1beta1.Config{}
-	if err := r.Get(ctx, types.NamespacedName{
-		Namespace: req.Namespace,
-		Name:      object.Spec.ConfigName,
-	}, config); err != nil {
+	config, err := getConfig(ctx, r.Client, req.Namespace, object.GetConfigRef())
+	if err != nil {
 		if apimachineryerrors.IsNotFound(err) {
 			reqLogger.Info("influxdb config not found, skipping deleting resources")
 			return nil
 		}
+		if errors.Is(err, ConfigNotAllowed) {
+			reqLogger.Info("influxdb config not allowed, skipping deleting resources")
+			return nil
+		}
 		reqLogger.Error(err, "failed to read influxdb config")
 		return err
 	}
 
-	// read secret with influxdb token
-	secret := &v1.Secret{}
-	if err := r.Get(
-		ctx,
-		types.NamespacedName{
-			Namespace: config.Spec.TokenSecretNamespace,
-			Name:      config.Spec.TokenSecretName,
-		},
-		secret,
-	); err != nil {
-		reqLogger.Error(err, "failed to read influxdb token")
+	newClient, err := r.Clients.Get(ctx, r.Client, config)
+	if err != nil {
+		reqLogger.Error(err, "failed to create influxdb client")
 		return err
 	}
-
-	newClient := influxdb.NewClient(config.Spec.Addr, string(secret.Data[keyToken]))
 	// always close client at the end
 	defer newClient.Close()
 
-	orgApi := newClient.OrganizationsAPI()
+	authorizationsApi := newClient.AuthorizationsAPI()
 
-	organization, err := orgApi.FindOrganizationByName(ctx, config.Spec.OrgName)
-	if err != nil {
-		httpErr := &http.Error{
-			StatusCode: 0,
-			Code:       "",
-			Message:    "",
-			Err:        nil,
-			RetryAfter: 0,
+	// lookup by description is only a fallback for objects without a recorded id
+	if len(tokenId) == 0 {
+		organization, err := newClient.FindConfigOrganization(ctx, config)
+		if err != nil {
+			httpErr := &http.Error{
+				StatusCode: 0,
+				Code:       "",
+				Message:    "",
+				Err:        nil,
+				RetryAfter: 0,
+			}
+			if errors.As(err, &httpErr) && httpErr.StatusCode == 404 {
+				reqLogger.Info("organization not found")
+				return nil
+			} else {
+				reqLogger.Error(err, "failed to find organization")
+				return err
+			}
 		}
-		if errors.As(err, &httpErr) && httpErr.StatusCode == 404 {
-			reqLogger.Info("organization not found")
-			return nil
-		} else {
-			reqLogger.Error(err, "failed to find organization")
+
+		if organization == nil || organization.Id == nil || len(*organization.Id) == 0 {
+			err := fmt.Errorf("nil org pointer or invalid id")
+			reqLogger.Error(err, "failed to get valid org id")
 			return err
 		}
-	}
-
-	if organization == nil || organization.Id == nil || len(*organization.Id) == 0 {
-		err := fmt.Errorf("nil org pointer or invalid id")
-		reqLogger.Error(err, "failed to get valid org id")
-		return err
-	}
 
-	authorizationsApi := newClient.AuthorizationsAPI()
+		authorizations, err := authorizationsApi.FindAuthorizationsByOrgID(ctx, *organization.Id)
+		if err != nil {
+			reqLogger.Error(err, "failed to find tokens by org id")
+			return err
+		}
 
-	var found bool
-	authorizations, err := authorizationsApi.FindAuthorizationsByOrgID(ctx, *organization.Id)
-	if err != nil {
-		reqLogger.Error(err, "failed to find tokens by org id")
-		return err
-	}
+		if authorizations == nil {
+			err := fmt.Errorf("recived nil authorizations")
+			reqLogger.Error(err, "failed to get valid authorizations")
+			return err
+		}
 
-	if authorizations == nil {
-		err := fmt.Errorf("recived nil authorizations")
-		reqLogger.Error(err, "failed to get valid authorizations")
-		return err
+		for _, authorization := range *authorizations {
+			if authorization.Description != nil &&
+				*authorization.Description == authorizationDescription &&
+				authorization.Id != nil {
+				tokenId = *authorization.Id
+				break
+			}
+		}
 	}
 
-	for _, authorization := range *authorizations {
-		if authorization.Description != nil &&
-			*authorization.Description == authorizationDescription {
-			tokenId = *authorization.Id
-			found = true
-			break
+	// tokens replaced by rotation or permission changes are deleted along with the current one
+	for _, previous := range object.Status.PreviousTokens {
+		if err := deleteAuthorization(ctx, authorizationsApi, previous.ID); err != nil {
+			reqLogger.Error(err, "failed to delete previous token")
+			return err
 		}
 	}
 
-	if !found {
+	if len(tokenId) == 0 {
 		reqLogger.Info("token not found")
 		return nil
 	}
 
 	if err := authorizationsApi.DeleteAuthorizationWithID(ctx, tokenId); err != nil {
+		if isHttpStatusCode(err, 404) {
+			reqLogger.Info("token not found")
+			return nil
+		}
 		reqLogger.Error(err, "failed to delete token")
 		return err
 	}
 
 	reqLogger.Info("token deleted")
 
-	found = false
+	var found bool
 	// Update the status of the object if pending
 	for i, condition := range object.Status.Conditions {
 		if condition.Reason == reasonDeletedToken {
@@ -192,10 +278,16 @@ func (r *TokenReconciler) FinalizeResources(ctx context.Context, clientObject cl
 			Message:            "deleted influxdb token",
 		}
 		object.Status = influxdbv1beta1.TokenStatus{
-			Phase:      object.Status.Phase,
-			Conditions: append(object.Status.Conditions, condition),
-			Message:    "deleted influxdb token",
-			Reason:     reasonDeletedToken,
+			Phase:            object.Status.Phase,
+			Conditions:       append(object.Status.Conditions, condition),
+			Message:          "deleted influxdb token",
+			Reason:           reasonDeletedToken,
+			Data:             object.Status.Data,
+			ID:               object.Status.ID,
+			OrgID:            object.Status.OrgID,
+			PreviousTokens:   object.Status.PreviousTokens,
+			LastRotationTime: object.Status.LastRotationTime,
+			NextRotationTime: object.Status.NextRotationTime,
 		}
 	}
 
@@ -316,7 +408,10 @@ func (r *TokenReconciler) ReconcileResources(ctx context.Context, clientObject c
 		return err
 	}
 
-	if object.Status.Data != nil {
+	// authorization id recorded in status is the primary way of identifying
+	// the token with id recorded in status data kept for older objects
+	tokenId = object.Status.ID
+	if len(tokenId) == 0 && object.Status.Data != nil {
 		if id, ok := object.Status.Data[keyTokenId]; ok {
 			tokenId = id
 		}
@@ -326,36 +421,21 @@ func (r *TokenReconciler) ReconcileResources(ctx context.Context, clientObject c
 	authorizationDescription := getAuthorizationDescription(object.Name, object.Namespace, string(object.UID))
 
 	// read config with influxdb info
-	config := &influxdbv1beta1.Config{}
-	if err := r.Get(ctx, types.NamespacedName{
-		Namespace: req.Namespace,
-		Name:      object.Spec.ConfigName,
-	}, config); err != nil {
+	config, err := getConfig(ctx, r.Client, req.Namespace, object.GetConfigRef())
+	if err != nil {
 		reqLogger.Error(err, "failed to read influxdb config")
 		return err
 	}
 
-	// read secret with influxdb token
-	secret := &v1.Secret{}
-	if err := r.Get(
-		ctx,
-		types.NamespacedName{
-			Namespace: config.Spec.TokenSecretNamespace,
-			Name:      config.Spec.TokenSecretName,
-		},
-		secret,
-	); err != nil {
-		reqLogger.Error(err, "failed to read influxdb token")
+	newClient, err := r.Clients.Get(ctx, r.Client, config)
+	if err != nil {
+		reqLogger.Error(err, "failed to create influxdb client")
 		return err
 	}
-
-	newClient := influxdb.NewClient(config.Spec.Addr, string(secret.Data[keyToken]))
 	// always close client at the end
 	defer newClient.Close()
 
-	orgApi := newClient.OrganizationsAPI()
-
-	organization, err := orgApi.FindOrganizationByName(ctx, config.Spec.OrgName)
+	organization, err := newClient.FindConfigOrganization(ctx, config)
 	if err != nil {
 		httpErr := &http.Error{
 			StatusCode: 0,
@@ -381,121 +461,216 @@ func (r *TokenReconciler) ReconcileResources(ctx context.Context, clientObject c
 
 	authorizationsApi := newClient.AuthorizationsAPI()
 
-	authorizations, err := authorizationsApi.FindAuthorizationsByOrgID(ctx, *organization.Id)
-	if err != nil {
-		reqLogger.Error(err, "failed to find tokens by org id")
-		return err
+	var authorization *domain.Authorization
+	if len(tokenId) > 0 {
+		authorization, err = findAuthorizationByID(ctx, newClient, tokenId)
+		if err != nil {
+			if !isHttpStatusCode(err, 404) {
+				reqLogger.Error(err, "failed to find token by id")
+				return err
+			}
+			reqLogger.Info("token not found by id")
+			authorization = nil
+		}
 	}
 
-	if authorizations == nil {
-		err := fmt.Errorf("recived nil authorizations")
-		reqLogger.Error(err, "failed to get valid authorizations")
-		return err
-	}
+	// lookup by description is only a fallback for adopting tokens without a recorded id
+	if authorization == nil {
+		authorizations, err := authorizationsApi.FindAuthorizationsByOrgID(ctx, *organization.Id)
+		if err != nil {
+			reqLogger.Error(err, "failed to find tokens by org id")
+			return err
+		}
 
-	for _, authorization := range *authorizations {
-		if authorization.Description != nil &&
-			*authorization.Description == authorizationDescription {
-			rateLimit(
-				fmt.Sprintf("%s-%s", object.UID, "token"),
-				time.Hour*24,
-				func() {
-					reqLogger.Info("token exists")
-				},
-			)
-			tokenExists = true
+		if authorizations == nil {
+			err := fmt.Errorf("recived nil authorizations")
+			reqLogger.Error(err, "failed to get valid authorizations")
+			return err
+		}
 
-			if authorization.Id == nil || authorization.Token == nil {
-				err := fmt.Errorf("received nil id or token in authorization")
-				reqLogger.Error(err, "failed to get valid authorization")
-				return err
+		for i := range *authorizations {
+			if (*authorizations)[i].Description != nil &&
+				*(*authorizations)[i].Description == authorizationDescription {
+				authorization = &(*authorizations)[i]
+				break
 			}
+		}
+	}
 
-			tokenId = *authorization.Id
-			token = *authorization.Token
-			break
+	if authorization != nil {
+		rateLimit(
+			fmt.Sprintf("%s-%s", object.UID, "token"),
+			time.Hour*24,
+			func() {
+				reqLogger.Info("token exists")
+			},
+		)
+		tokenExists = true
+
+		if authorization.Id == nil || authorization.Token == nil {
+			err := fmt.Errorf("received nil id or token in authorization")
+			reqLogger.Error(err, "failed to get valid authorization")
+			return err
 		}
+
+		tokenId = *authorization.Id
+		token = *authorization.Token
 	}
 
+	// bucket scoped permissions need bucket ids. a recreated bucket gets a new id
+	// causing permissions to differ and the token to be replaced
+	buckets, err := getPermissionBuckets(
+		ctx,
+		r.Client,
+		newClient.BucketsAPI(),
+		object.Namespace,
+		*organization.Id,
+		object.Spec.Permissions,
+	)
+	if err != nil {
+		reqLogger.Error(err, "failed to resolve buckets in permissions")
+		r.Recorder.Event(object, v1.EventTypeWarning, reasonWaitingForBucket, err.Error())
+		return err
+	}
+
+	permissions := getPermissions(object.Spec.Permissions, organization, buckets)
+
 	if !tokenExists {
-		permissions := make([]domain.Permission, len(object.Spec.Permissions))
-		for i, permission := range object.Spec.Permissions {
-			permission := permission
-			name := new(string)
-			if len(permission.ResourceName) > 0 {
-				*name = permission.ResourceName
-			}
-			if permission.ResourceType == influxdbv1beta1.ResourceTypeOrgs {
-				permissions[i] = domain.Permission{
-					Action: domain.PermissionAction(permission.PermissionType),
-					Resource: domain.Resource{
-						Id:    nil,
-						Name:  name,
-						Org:   nil,
-						OrgID: nil,
-						Type:  domain.ResourceType(permission.ResourceType),
-					},
-				}
-			} else {
-				permissions[i] = domain.Permission{
-					Action: domain.PermissionAction(permission.PermissionType),
-					Resource: domain.Resource{
-						Id:    nil,
-						Name:  name,
-						Org:   &organization.Name,
-						OrgID: organization.Id,
-						Type:  domain.ResourceType(permission.ResourceType),
-					},
-				}
-			}
+		authorization, err := createAuthorization(
+			ctx,
+			authorizationsApi,
+			organization,
+			authorizationDescription,
+			permissions,
+		)
+		if err != nil {
+			reqLogger.Error(err, "failed to create token")
+			return err
 		}
 
-		if authorization, err := authorizationsApi.CreateAuthorization(
+		reqLogger.Info("token created")
+		tokenCreated = true
+		tokenId = *authorization.Id
+		token = *authorization.Token
+	}
+
+	// influxdb authorizations cannot change permissions, hence a replacement is
+	// created. replaced token is recorded in status as a previous token expiring
+	// right away, which is revoked once the secret holds the replacement. Tokens
+	// replaced by an earlier rotation remain valid until their overlap elapses
+	if tokenExists && !equalPermissions(authorization.Permissions, permissions) {
+		replacement, err := createAuthorization(
 			ctx,
-			&domain.Authorization{
-				AuthorizationUpdateRequest: domain.AuthorizationUpdateRequest{
-					Description: &authorizationDescription,
-					Status:      nil,
-				},
-				CreatedAt:   nil,
-				Id:          nil,
-				Links:       nil,
-				Org:         nil,
-				OrgID:       organization.Id,
-				Permissions: &permissions,
-				Token:       nil,
-				UpdatedAt:   nil,
-				User:        nil,
-				UserID:      nil,
-			},
-		); err != nil {
-			httpErr := &http.Error{
-				StatusCode: 0,
-				Code:       "",
-				Message:    "",
-				Err:        nil,
-				RetryAfter: 0,
-			}
-			if errors.As(err, &httpErr) && httpErr.StatusCode == 422 {
-				rateLimit(
-					fmt.Sprintf("%s-%s", object.UID, "token"),
-					time.Hour*24,
-					func() {
-						reqLogger.Info("token exists")
-					},
-				)
-				tokenId = *authorization.Id
-				token = *authorization.Token
-			} else {
-				reqLogger.Error(err, "failed to create token")
-				return err
-			}
-		} else {
-			reqLogger.Info("token created")
-			tokenCreated = true
-			tokenId = *authorization.Id
-			token = *authorization.Token
+			authorizationsApi,
+			organization,
+			authorizationDescription,
+			permissions,
+		)
+		if err != nil {
+			reqLogger.Error(err, "failed to create replacement token")
+			return err
+		}
+
+		reqLogger.Info("replacement token created")
+
+		now := time.Now()
+		object.Status.PreviousTokens = append(object.Status.PreviousTokens, influxdbv1beta1.PreviousToken{
+			ID:         tokenId,
+			ExpiryTime: v12.Time{Time: now},
+		})
+		if object.Spec.Rotation != nil {
+			object.Status.LastRotationTime = &v12.Time{Time: now}
+			object.Status.NextRotationTime = &v12.Time{Time: now.Add(object.Spec.Rotation.Period.Duration)}
 		}
+
+		// secret is updated on the next pass once both tokens are tracked in status
+		if err := r.recordReplacementToken(ctx, object, authorizationsApi, replacement, *organization.Id); err != nil {
+			return err
+		}
+
+		r.Recorder.Event(
+			object,
+			v1.EventTypeNormal,
+			reasonReplacedToken,
+			fmt.Sprintf("replaced influxdb token %s to apply permission changes", tokenId),
+		)
+		return ObjectUpdated
+	}
+
+	// rotate token once it is due. replaced token remains valid until
+	// overlap elapses allowing workloads to pick up the new one
+	if object.Spec.Rotation != nil && tokenExists &&
+		object.Status.NextRotationTime != nil &&
+		!time.Now().Before(object.Status.NextRotationTime.Time) {
+		authorization, err := createAuthorization(
+			ctx,
+			authorizationsApi,
+			organization,
+			authorizationDescription,
+			permissions,
+		)
+		if err != nil {
+			reqLogger.Error(err, "failed to create rotated token")
+			return err
+		}
+
+		reqLogger.Info("rotated token created")
+
+		now := time.Now()
+		object.Status.PreviousTokens = append(object.Status.PreviousTokens, influxdbv1beta1.PreviousToken{
+			ID:         tokenId,
+			ExpiryTime: v12.Time{Time: now.Add(object.Spec.Rotation.Overlap.Duration)},
+		})
+		object.Status.LastRotationTime = &v12.Time{Time: now}
+		object.Status.NextRotationTime = &v12.Time{Time: now.Add(object.Spec.Rotation.Period.Duration)}
+
+		// secret is updated on the next pass once both tokens are tracked in status
+		if err := r.recordReplacementToken(ctx, object, authorizationsApi, authorization, *organization.Id); err != nil {
+			return err
+		}
+
+		reqLogger.Info("token rotated")
+		r.Recorder.Event(object, v1.EventTypeNormal, reasonRotatedToken, "rotated influxdb token")
+		return ObjectUpdated
+	}
+
+	// keep rotation schedule in sync with the spec
+	var scheduleChanged bool
+	if object.Spec.Rotation != nil {
+		if object.Status.LastRotationTime == nil || tokenCreated {
+			object.Status.LastRotationTime = &v12.Time{Time: time.Now()}
+			scheduleChanged = true
+		}
+
+		next := object.Status.LastRotationTime.Add(object.Spec.Rotation.Period.Duration)
+		if object.Status.NextRotationTime == nil || !object.Status.NextRotationTime.Time.Equal(next) {
+			object.Status.NextRotationTime = &v12.Time{Time: next}
+			scheduleChanged = true
+		}
+	} else if object.Status.NextRotationTime != nil {
+		object.Status.NextRotationTime = nil
+		scheduleChanged = true
+	}
+
+	// secret data is laid out per secret template
+	var secretLabels, secretAnnotations map[string]string
+	if object.Spec.SecretTemplate != nil {
+		secretLabels = object.Spec.SecretTemplate.Labels
+		secretAnnotations = object.Spec.SecretTemplate.Annotations
+	}
+	secretData, err := getTokenSecretData(
+		object.Spec.SecretTemplate,
+		tokenSecretValues{
+			Token:  token,
+			URL:    newClient.ServerURL(),
+			Org:    organization.Name,
+			OrgID:  *organization.Id,
+			Bucket: getTokenSecretBucket(buckets),
+		},
+	)
+	if err != nil {
+		reqLogger.Error(err, "failed to render secret data")
+		return err
 	}
 
 	if tokenExists || tokenCreated {
@@ -512,8 +687,8 @@ func (r *TokenReconciler) ReconcileResources(ctx context.Context, clientObject c
 				CreationTimestamp:          v12.Time{Time: time.Now()},
 				DeletionTimestamp:          nil,
 				DeletionGracePeriodSeconds: nil,
-				Labels:                     nil,
-				Annotations:                nil,
+				Labels:                     secretLabels,
+				Annotations:                secretAnnotations,
 				OwnerReferences: []v12.OwnerReference{
 					{
 						APIVersion:         object.APIVersion,
@@ -528,10 +703,8 @@ func (r *TokenReconciler) ReconcileResources(ctx context.Context, clientObject c
 				ClusterName:   "",
 				ManagedFields: nil,
 			},
-			Immutable: nil,
-			Data: map[string][]byte{
-				keyToken: []byte(token),
-			},
+			Immutable:  nil,
+			Data:       secretData,
 			StringData: nil,
 			Type:       "",
 		}
@@ -555,8 +728,8 @@ func (r *TokenReconciler) ReconcileResources(ctx context.Context, clientObject c
 		}
 	}
 
-	// update secret if already exists but does not contain
-	// data matching with token
+	// update secret if already exists but does not contain data
+	// matching with token or lacks labels and annotations of the template
 	if secretExists {
 		secret := &v1.Secret{}
 		if err := r.Get(ctx, types.NamespacedName{
@@ -567,11 +740,33 @@ func (r *TokenReconciler) ReconcileResources(ctx context.Context, clientObject c
 			return err
 		}
 
-		if secret.Data == nil || string(secret.Data[keyToken]) != token {
-			secret.Data = map[string][]byte{
-				keyToken: []byte(token),
+		var secretChanged bool
+		if !reflect.DeepEqual(secret.Data, secretData) {
+			secret.Data = secretData
+			secretChanged = true
+		}
+
+		for k, v := range secretLabels {
+			if value, ok := secret.Labels[k]; !ok || value != v {
+				if secret.Labels == nil {
+					secret.Labels = make(map[string]string)
+				}
+				secret.Labels[k] = v
+				secretChanged = true
 			}
+		}
 
+		for k, v := range secretAnnotations {
+			if value, ok := secret.Annotations[k]; !ok || value != v {
+				if secret.Annotations == nil {
+					secret.Annotations = make(map[string]string)
+				}
+				secret.Annotations[k] = v
+				secretChanged = true
+			}
+		}
+
+		if secretChanged {
 			if err := r.Update(ctx, secret); err != nil {
 				reqLogger.Error(err, "failed to update secret")
 				return err
@@ -581,14 +776,44 @@ func (r *TokenReconciler) ReconcileResources(ctx context.Context, clientObject c
 		}
 	}
 
+	// tokens replaced by rotation or by permission changes are deleted once
+	// the secret holds the current token and their overlap, if any, elapses
+	var previousDeleted bool
+	previousTokens := make([]influxdbv1beta1.PreviousToken, 0, len(object.Status.PreviousTokens))
+	for _, previous := range object.Status.PreviousTokens {
+		if time.Now().Before(previous.ExpiryTime.Time) {
+			previousTokens = append(previousTokens, previous)
+			continue
+		}
+
+		if err := deleteAuthorization(ctx, authorizationsApi, previous.ID); err != nil {
+			reqLogger.Error(err, "failed to delete previous token")
+			return err
+		}
+
+		reqLogger.Info("previous token deleted")
+		previousDeleted = true
+	}
+	if previousDeleted {
+		object.Status.PreviousTokens = previousTokens
+	}
+
+	// record influxdb ids so that subsequent lookups are direct
+	var idChanged bool
+	if len(tokenId) > 0 && (object.Status.ID != tokenId || object.Status.OrgID != *organization.Id) {
+		object.Status.ID = tokenId
+		object.Status.OrgID = *organization.Id
+		object.Status.Data = map[string]string{
+			keyTokenId: tokenId,
+		}
+		idChanged = true
+	}
+
 	// Update the status of the object if pending
 	for i, condition := range object.Status.Conditions {
 		if condition.Reason == reasonCreatedToken {
 			if tokenCreated {
 				object.Status.Conditions[i].LastTransitionTime = v12.Time{Time: time.Now()}
-				object.Status.Data = map[string]string{
-					keyTokenId: tokenId,
-				}
 			}
 			found = true
 			break
@@ -604,10 +829,16 @@ func (r *TokenReconciler) ReconcileResources(ctx context.Context, clientObject c
 			Message:            "created influxdb token",
 		}
 		object.Status = influxdbv1beta1.TokenStatus{
-			Phase:      phaseReady,
-			Conditions: append(object.Status.Conditions, condition),
-			Message:    "created influxdb token",
-			Reason:     reasonCreatedToken,
+			Phase:            phaseReady,
+			Conditions:       append(object.Status.Conditions, condition),
+			Message:          "created influxdb token",
+			Reason:           reasonCreatedToken,
+			Data:             object.Status.Data,
+			ID:               object.Status.ID,
+			OrgID:            object.Status.OrgID,
+			PreviousTokens:   object.Status.PreviousTokens,
+			LastRotationTime: object.Status.LastRotationTime,
+			NextRotationTime: object.Status.NextRotationTime,
 		}
 		if err := r.Status().Update(ctx, object); err != nil {
 			reqLogger.Error(err, "failed to update object status")
@@ -617,7 +848,7 @@ func (r *TokenReconciler) ReconcileResources(ctx context.Context, clientObject c
 			return ObjectUpdated
 		}
 	} else {
-		if tokenCreated {
+		if tokenCreated || idChanged || previousDeleted || scheduleChanged {
 			if err := r.Status().Update(ctx, object); err != nil {
 				reqLogger.Error(err, "failed to update object status")
 				return err
@@ -631,6 +862,103 @@ func (r *TokenReconciler) ReconcileResources(ctx context.Context, clientObject c
 	return nil
 }
 
+// recordReplacementToken records authorization replacing the current one in status
+// before the secret is updated, so that neither of them is left untracked when a
+// later step fails. Replacement is revoked if it cannot be recorded
+func (r *TokenReconciler) recordReplacementToken(
+	ctx context.Context,
+	object *influxdbv1beta1.Token,
+	authorizationsApi api.AuthorizationsAPI,
+	replacement *domain.Authorization,
+	orgId string,
+) error {
+	reqLogger := log.FromContext(ctx)
+
+	object.Status.ID = *replacement.Id
+	object.Status.OrgID = orgId
+	object.Status.Data = map[string]string{
+		keyTokenId: *replacement.Id,
+	}
+
+	if err := r.Status().Update(ctx, object); err != nil {
+		reqLogger.Error(err, "failed to update object status")
+		if err := deleteAuthorization(ctx, authorizationsApi, *replacement.Id); err != nil {
+			reqLogger.Error(err, "failed to delete unrecorded token")
+		}
+		return err
+	}
+
+	reqLogger.Info("updated object status")
+	return nil
+}
+
 func getAuthorizationDescription(name, namespace, uid string) string {
 	return fmt.Sprintf("%s.%s.%s", name, namespace, uid)
 }
+
+// findAuthorizationByID fetches authorization by its id. Authorizations api
+// does not offer such lookup, so generated api client is used directly
+func findAuthorizationByID(ctx context.Context, newClient influxdb.Client, id string) (*domain.Authorization, error) {
+	response, err := domain.NewClientWithResponses(newClient.HTTPService()).
+		GetAuthorizationsIDWithResponse(ctx, id, &domain.GetAuthorizationsIDParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	if response.JSONDefault != nil {
+		return nil, domain.ErrorToHTTPError(response.JSONDefault, response.StatusCode())
+	}
+
+	if response.JSON200 == nil {
+		return nil, fmt.Errorf("received nil authorization")
+	}
+
+	return response.JSON200, nil
+}
+
+// createAuthorization creates an authorization in the org with given permissions
+func createAuthorization(
+	ctx context.Context,
+	authorizationsApi api.AuthorizationsAPI,
+	organization *domain.Organization,
+	description string,
+	permissions []domain.Permission,
+) (*domain.Authorization, error) {
+	authorization, err := authorizationsApi.CreateAuthorization(
+		ctx,
+		&domain.Authorization{
+			AuthorizationUpdateRequest: domain.AuthorizationUpdateRequest{
+				Description: &description,
+				Status:      nil,
+			},
+			CreatedAt:   nil,
+			Id:          nil,
+			Links:       nil,
+			Org:         nil,
+			OrgID:       organization.Id,
+			Permissions: &permissions,
+			Token:       nil,
+			UpdatedAt:   nil,
+			User:        nil,
+			UserID:      nil,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if authorization == nil || authorization.Id == nil || authorization.Token == nil {
+		return nil, fmt.Errorf("received nil id or token in authorization")
+	}
+
+	return authorization, nil
+}
+
+// deleteAuthorization deletes authorization by id treating missing authorization as deleted
+func deleteAuthorization(ctx context.Context, authorizationsApi api.AuthorizationsAPI, id string) error {
+	if err := authorizationsApi.DeleteAuthorizationWithID(ctx, id); err != nil && !isHttpStatusCode(err, 404) {
+		return err
+	}
+
+	return nil
+}
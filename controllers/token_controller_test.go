@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+)
+
+// newTokenTest returns token reconciler and a token in an org of fake influxdb
+func newTokenTest(t *testing.T) (*fakeInfluxdb, string, *TokenReconciler, *influxdbv1beta1.Token) {
+	server := newFakeInfluxdb(t)
+	orgId := server.CreateOrg("team")
+
+	object := &influxdbv1beta1.Token{
+		ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: "team", UID: "reader-uid"},
+		Spec: influxdbv1beta1.TokenSpec{
+			ConfigName: "default",
+			SecretName: "reader-token",
+			Permissions: []influxdbv1beta1.Permission{
+				{
+					ResourceType:   influxdbv1beta1.ResourceTypeBuckets,
+					PermissionType: influxdbv1beta1.PermissionRead,
+				},
+			},
+		},
+	}
+
+	r := &TokenReconciler{
+		Client: newFakeClient(
+			server.Config("team", "default", "team"),
+			server.TokenSecret("team"),
+			object,
+		),
+		Scheme:                scheme.Scheme,
+		Recorder:              record.NewFakeRecorder(100),
+		Clients:               NewClientPool(),
+		DefaultDeletionPolicy: influxdbv1beta1.DeletionPolicyDelete,
+	}
+
+	return server, orgId, r, object
+}
+
+// getTokenSecretValue returns token held by the token secret
+func getTokenSecretValue(t *testing.T, r *TokenReconciler, object *influxdbv1beta1.Token) string {
+	secret := getObject(t, r.Client, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: object.Spec.SecretName, Namespace: object.Namespace},
+	})
+	if secret == nil {
+		t.Fatalf("token secret not found")
+	}
+
+	return string(secret.(*v1.Secret).Data[keyToken])
+}
+
+// getToken returns current state of the token
+func getToken(t *testing.T, r *TokenReconciler, object *influxdbv1beta1.Token) *influxdbv1beta1.Token {
+	current := getObject(t, r.Client, object)
+	if current == nil {
+		t.Fatalf("token not found")
+	}
+
+	return current.(*influxdbv1beta1.Token)
+}
+
+func TestTokenReconcileRotation(t *testing.T) {
+	ctx := context.Background()
+	server, orgId, r, object := newTokenTest(t)
+	object.Spec.Rotation = &influxdbv1beta1.TokenRotation{
+		Period:  metav1.Duration{Duration: time.Hour},
+		Overlap: metav1.Duration{Duration: time.Hour},
+	}
+	if err := r.Update(ctx, object); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reconcileObject(t, r, r.Client, object); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	object = getToken(t, r, object)
+	firstId := object.Status.ID
+	if server.Authorization(firstId) == nil {
+		t.Fatalf("token %q not created", firstId)
+	}
+	if got, want := getTokenSecretValue(t, r, object), "token-"+firstId; got != want {
+		t.Errorf("secret token = %s, want %s", got, want)
+	}
+	if object.Status.NextRotationTime == nil ||
+		!object.Status.NextRotationTime.Time.Equal(object.Status.LastRotationTime.Add(time.Hour)) {
+		t.Fatalf("next rotation time = %v, want an hour after %v",
+			object.Status.NextRotationTime, object.Status.LastRotationTime)
+	}
+
+	// rotation is due
+	object.Status.NextRotationTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+	if err := r.Status().Update(ctx, object); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reconcileObject(t, r, r.Client, object); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	object = getToken(t, r, object)
+	secondId := object.Status.ID
+	if secondId == firstId {
+		t.Fatalf("token not rotated")
+	}
+	if got, want := getTokenSecretValue(t, r, object), "token-"+secondId; got != want {
+		t.Errorf("secret token = %s, want %s", got, want)
+	}
+	if len(object.Status.PreviousTokens) != 1 || object.Status.PreviousTokens[0].ID != firstId {
+		t.Fatalf("previous tokens = %v, want %s", object.Status.PreviousTokens, firstId)
+	}
+
+	// replaced token remains valid during overlap
+	if server.Authorization(firstId) == nil {
+		t.Errorf("replaced token deleted before overlap elapsed")
+	}
+	if got := server.AuthorizationCount(orgId); got != 2 {
+		t.Errorf("authorization count = %d, want 2", got)
+	}
+
+	// rotation is due again before the overlap of the replaced token elapses
+	object.Status.NextRotationTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+	if err := r.Status().Update(ctx, object); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reconcileObject(t, r, r.Client, object); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	object = getToken(t, r, object)
+	thirdId := object.Status.ID
+	if thirdId == secondId {
+		t.Fatalf("token not rotated")
+	}
+	if len(object.Status.PreviousTokens) != 2 {
+		t.Fatalf("previous tokens = %v, want %s and %s", object.Status.PreviousTokens, firstId, secondId)
+	}
+	if got := server.AuthorizationCount(orgId); got != 3 {
+		t.Errorf("authorization count = %d, want 3", got)
+	}
+
+	// overlap of the first token elapses
+	for i := range object.Status.PreviousTokens {
+		if object.Status.PreviousTokens[i].ID == firstId {
+			object.Status.PreviousTokens[i].ExpiryTime = metav1.Time{Time: time.Now().Add(-time.Minute)}
+		}
+	}
+	if err := r.Status().Update(ctx, object); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reconcileObject(t, r, r.Client, object); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	object = getToken(t, r, object)
+	if len(object.Status.PreviousTokens) != 1 || object.Status.PreviousTokens[0].ID != secondId {
+		t.Errorf("previous tokens = %v, want %s", object.Status.PreviousTokens, secondId)
+	}
+	if server.Authorization(firstId) != nil {
+		t.Errorf("replaced token not deleted after overlap elapsed")
+	}
+	for _, id := range []string{secondId, thirdId} {
+		if server.Authorization(id) == nil {
+			t.Errorf("token %q deleted", id)
+		}
+	}
+}
+
+func TestTokenReconcilePermissionChange(t *testing.T) {
+	ctx := context.Background()
+	server, orgId, r, object := newTokenTest(t)
+
+	if err := reconcileObject(t, r, r.Client, object); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	object = getToken(t, r, object)
+	firstId := object.Status.ID
+
+	object.Spec.Permissions[0].PermissionType = influxdbv1beta1.PermissionWrite
+	if err := r.Update(ctx, object); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reconcileObject(t, r, r.Client, object); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	object = getToken(t, r, object)
+	secondId := object.Status.ID
+	if secondId == firstId {
+		t.Fatalf("token not replaced")
+	}
+	if got, want := getTokenSecretValue(t, r, object), "token-"+secondId; got != want {
+		t.Errorf("secret token = %s, want %s", got, want)
+	}
+
+	authorization := server.Authorization(secondId)
+	if authorization == nil || authorization.Permissions == nil || len(*authorization.Permissions) != 1 ||
+		string((*authorization.Permissions)[0].Action) != influxdbv1beta1.PermissionWrite {
+		t.Errorf("replacement token permissions = %v, want write", authorization)
+	}
+
+	// replaced token is revoked once the secret holds the replacement
+	if server.Authorization(firstId) != nil {
+		t.Errorf("replaced token not deleted")
+	}
+	if len(object.Status.PreviousTokens) != 0 {
+		t.Errorf("previous tokens = %v, want none", object.Status.PreviousTokens)
+	}
+	if got := server.AuthorizationCount(orgId); got != 1 {
+		t.Errorf("authorization count = %d, want 1", got)
+	}
+}
+
+func TestTokenReconcileDeletionPolicy(t *testing.T) {
+	tests := []struct {
+		policy      string
+		wantDeleted bool
+		wantOwned   bool
+	}{
+		{
+			policy:      influxdbv1beta1.DeletionPolicyDelete,
+			wantDeleted: true,
+			wantOwned:   true,
+		},
+		{
+			policy:    influxdbv1beta1.DeletionPolicyRetain,
+			wantOwned: true,
+		},
+		{
+			policy: influxdbv1beta1.DeletionPolicyOrphan,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			ctx := context.Background()
+			server, _, r, object := newTokenTest(t)
+			object.Spec.DeletionPolicy = tt.policy
+			if err := r.Update(ctx, object); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			object = getToken(t, r, object)
+			id := object.Status.ID
+			if err := r.Delete(ctx, object); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := reconcileObject(t, r, r.Client, object); err != nil {
+				t.Fatalf("failed to reconcile: %v", err)
+			}
+
+			if getObject(t, r.Client, object) != nil {
+				t.Fatalf("token object not deleted")
+			}
+			if deleted := server.Authorization(id) == nil; deleted != tt.wantDeleted {
+				t.Errorf("token deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+
+			// secret is garbage collected along with its owner unless released
+			secret := getObject(t, r.Client, &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: object.Spec.SecretName, Namespace: object.Namespace},
+			})
+			if secret == nil {
+				t.Fatalf("token secret not found")
+			}
+			if owned := len(secret.GetOwnerReferences()) > 0; owned != tt.wantOwned {
+				t.Errorf("secret owned = %v, want %v", owned, tt.wantOwned)
+			}
+		})
+	}
+}
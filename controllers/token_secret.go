@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+)
+
+// tokenSecretValues are the values published in token secret
+// and available to secret templates
+type tokenSecretValues struct {
+	Token  string
+	URL    string
+	Org    string
+	OrgID  string
+	Bucket string
+}
+
+// getTokenSecretData returns token secret data laid out per secret template
+func getTokenSecretData(secretTemplate *influxdbv1beta1.SecretTemplate, values tokenSecretValues) (map[string][]byte, error) {
+	data := map[string][]byte{
+		keyToken: []byte(values.Token),
+	}
+
+	if secretTemplate == nil {
+		return data, nil
+	}
+
+	for _, key := range secretTemplate.Keys {
+		switch key {
+		case influxdbv1beta1.SecretKeyURL:
+			data[key] = []byte(values.URL)
+		case influxdbv1beta1.SecretKeyOrg:
+			data[key] = []byte(values.Org)
+		case influxdbv1beta1.SecretKeyOrgID:
+			data[key] = []byte(values.OrgID)
+		case influxdbv1beta1.SecretKeyBucket:
+			data[key] = []byte(values.Bucket)
+		default:
+			return nil, fmt.Errorf("invalid secret key %s", key)
+		}
+	}
+
+	for key, value := range secretTemplate.Data {
+		tmpl, err := template.New(key).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template for secret key %s: %w", key, err)
+		}
+
+		bb := &bytes.Buffer{}
+		if err := tmpl.Execute(bb, values); err != nil {
+			return nil, fmt.Errorf("failed to render template for secret key %s: %w", key, err)
+		}
+
+		data[key] = bb.Bytes()
+	}
+
+	return data, nil
+}
+
+// getTokenSecretBucket returns name of the first bucket that permissions are scoped to
+func getTokenSecretBucket(buckets []*domain.Bucket) string {
+	for _, bucket := range buckets {
+		if bucket != nil {
+			return bucket.Name
+		}
+	}
+
+	return ""
+}
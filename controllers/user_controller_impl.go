@@ -0,0 +1,553 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func (r *UserReconciler) FinalizeStatus(ctx context.Context, clientObject client.Object) error {
+	if !controllerutil.ContainsFinalizer(clientObject, finalizer) {
+		return nil
+	}
+
+	reqLogger := log.FromContext(ctx)
+
+	object, ok := clientObject.(*influxdbv1beta1.User)
+	if !ok {
+		err := fmt.Errorf("cientObject to object type assertion error")
+		reqLogger.Error(err, "failed to get object instance")
+		return err
+	}
+
+	// Update the status of the object if not terminating
+	if object.Status.Phase != phaseTerminating {
+		object.Status.Phase = phaseTerminating
+		object.Status.Message = "object is marked for deletion"
+		object.Status.Reason = reasonObjectMarkedForDeletion
+		if err := r.Status().Update(ctx, object); err != nil {
+			reqLogger.Error(err, "failed to update object status")
+			return err
+		} else {
+			reqLogger.Info("updated object status")
+			return ObjectUpdated
+		}
+	}
+
+	return nil
+}
+
+func (r *UserReconciler) FinalizeResources(ctx context.Context, clientObject client.Object, req ctrl.Request) error {
+	if !controllerutil.ContainsFinalizer(clientObject, finalizer) {
+		return nil
+	}
+
+	reqLogger := log.FromContext(ctx)
+
+	object, ok := clientObject.(*influxdbv1beta1.User)
+	if !ok {
+		err := fmt.Errorf("cientObject to object type assertion error")
+		reqLogger.Error(err, "failed to get object instance")
+		return err
+	}
+
+	if policy := getDeletionPolicy(object.Spec.DeletionPolicy, r.DefaultDeletionPolicy); policy != influxdbv1beta1.DeletionPolicyDelete {
+		for _, condition := range object.Status.Conditions {
+			if condition.Reason == reasonRetainedUser {
+				return nil
+			}
+		}
+
+		message := fmt.Sprintf("retained influxdb user per %s deletion policy", policy)
+		reqLogger.Info(message)
+		r.Recorder.Event(object, v1.EventTypeNormal, reasonRetainedUser, message)
+
+		object.Status.Conditions = append(object.Status.Conditions, v12.Condition{
+			Type:               conditionTypeInfluxdb,
+			Status:             v12.ConditionTrue,
+			ObservedGeneration: 0,
+			LastTransitionTime: v12.Time{Time: time.Now()},
+			Reason:             reasonRetainedUser,
+			Message:            message,
+		})
+		object.Status.Message = message
+		object.Status.Reason = reasonRetainedUser
+
+		if err := r.Status().Update(ctx, object); err != nil {
+			reqLogger.Error(err, "failed to update object status")
+			return err
+		} else {
+			reqLogger.Info("updated object status")
+			return ObjectUpdated
+		}
+	}
+
+	// users cannot be labeled in influxdb, hence only a user whose id got
+	// recorded after it was created or adopted by this object is deleted
+	if len(object.Status.ID) == 0 {
+		reqLogger.Info("user not created by object, skipping deleting resources")
+		return nil
+	}
+
+	// read config with influxdb info
+	config, err := getConfig(ctx, r.Client, req.Namespace, object.GetConfigRef())
+	if err != nil {
+		if apimachineryerrors.IsNotFound(err) {
+			reqLogger.Info("influxdb config not found, skipping deleting resources")
+			return nil
+		}
+		if errors.Is(err, ConfigNotAllowed) {
+			reqLogger.Info("influxdb config not allowed, skipping deleting resources")
+			return nil
+		}
+		reqLogger.Error(err, "failed to read influxdb config")
+		return err
+	}
+
+	newClient, err := r.Clients.Get(ctx, r.Client, config)
+	if err != nil {
+		reqLogger.Error(err, "failed to create influxdb client")
+		return err
+	}
+	// always close client at the end
+	defer newClient.Close()
+
+	if err := newClient.UsersAPI().DeleteUserWithID(ctx, object.Status.ID); err != nil {
+		if isHttpStatusCode(err, 404) {
+			reqLogger.Info("user not found")
+			return nil
+		}
+		reqLogger.Error(err, "failed to delete user")
+		return err
+	}
+
+	reqLogger.Info("user deleted")
+
+	var found bool
+	// Update the status of the object if pending
+	for i, condition := range object.Status.Conditions {
+		if condition.Reason == reasonDeletedUser {
+			object.Status.Conditions[i].LastTransitionTime = v12.Time{Time: time.Now()}
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		object.Status.Conditions = append(object.Status.Conditions, v12.Condition{
+			Type:               conditionTypeInfluxdb,
+			Status:             v12.ConditionTrue,
+			ObservedGeneration: 0,
+			LastTransitionTime: v12.Time{Time: time.Now()},
+			Reason:             reasonDeletedUser,
+			Message:            "deleted influxdb user",
+		})
+		object.Status.Message = "deleted influxdb user"
+		object.Status.Reason = reasonDeletedUser
+	}
+
+	if err := r.Status().Update(ctx, object); err != nil {
+		reqLogger.Error(err, "failed to update object status")
+		return err
+	} else {
+		reqLogger.Info("updated object status")
+		return ObjectUpdated
+	}
+}
+
+func (r *UserReconciler) RemoveFinalizer(ctx context.Context, clientObject client.Object) error {
+	if !controllerutil.ContainsFinalizer(clientObject, finalizer) {
+		return nil
+	}
+
+	reqLogger := log.FromContext(ctx)
+
+	controllerutil.RemoveFinalizer(clientObject, finalizer)
+	if err := r.Update(ctx, clientObject); err != nil {
+		reqLogger.Error(err, "failed to remove finalizer")
+		return err
+	}
+	reqLogger.Info("finalizer removed")
+	return ObjectUpdated
+}
+
+func (r *UserReconciler) AddFinalizer(ctx context.Context, clientObject client.Object) error {
+	if controllerutil.ContainsFinalizer(clientObject, finalizer) {
+		return nil
+	}
+
+	reqLogger := log.FromContext(ctx)
+
+	controllerutil.AddFinalizer(clientObject, finalizer)
+	if err := r.Update(ctx, clientObject); err != nil {
+		reqLogger.Error(err, "failed to add finalizer")
+		return err
+	}
+	reqLogger.Info("finalizer added")
+	return ObjectUpdated
+}
+
+func (r *UserReconciler) InitializeStatus(ctx context.Context, clientObject client.Object) error {
+	reqLogger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(clientObject, finalizer) {
+		err := fmt.Errorf("finalizer not found")
+		reqLogger.Error(err, "failed to detect finalizer")
+		return err
+	}
+
+	object, ok := clientObject.(*influxdbv1beta1.User)
+	if !ok {
+		err := fmt.Errorf("cientObject to object type assertion error")
+		reqLogger.Error(err, "failed to get object instance")
+		return err
+	}
+
+	// Update the status of the object if none exists
+	found := false
+	for _, condition := range object.Status.Conditions {
+		if condition.Reason == reasonFinalizerAdded {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		object.Status = influxdbv1beta1.UserStatus{
+			Phase: phasePending,
+			Conditions: []v12.Condition{
+				{
+					Type:               conditionTypeObject,
+					Status:             v12.ConditionTrue,
+					ObservedGeneration: 0,
+					LastTransitionTime: v12.Time{Time: time.Now()},
+					Reason:             reasonFinalizerAdded,
+					Message:            "object initialized",
+				},
+			},
+			Message: "object initialized",
+			Reason:  reasonObjectInitialized,
+		}
+		if err := r.Status().Update(ctx, object); err != nil {
+			reqLogger.Error(err, "failed to update object status")
+			return err
+		} else {
+			reqLogger.Info("updated object status")
+			return ObjectUpdated
+		}
+	}
+
+	return nil
+}
+
+func (r *UserReconciler) ReconcileResources(ctx context.Context, clientObject client.Object, req ctrl.Request) error {
+	reqLogger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(clientObject, finalizer) {
+		err := fmt.Errorf("finalizer not found")
+		reqLogger.Error(err, "failed to detect finalizer")
+		return err
+	}
+
+	object, ok := clientObject.(*influxdbv1beta1.User)
+	if !ok {
+		err := fmt.Errorf("cientObject to object type assertion error")
+		reqLogger.Error(err, "failed to get object instance")
+		return err
+	}
+
+	// password is read before creating the user so that a missing
+	// secret does not leave the user without a password
+	var password, passwordVersion string
+	if object.Spec.PasswordSecret != nil {
+		var err error
+		password, passwordVersion, err = getUserPassword(ctx, r.Client, object)
+		if err != nil {
+			reqLogger.Error(err, "failed to read user password")
+			r.Recorder.Event(object, v1.EventTypeWarning, reasonPasswordNotFound, err.Error())
+			return err
+		}
+	}
+
+	// read config with influxdb info
+	config, err := getConfig(ctx, r.Client, req.Namespace, object.GetConfigRef())
+	if err != nil {
+		reqLogger.Error(err, "failed to read influxdb config")
+		return err
+	}
+
+	newClient, err := r.Clients.Get(ctx, r.Client, config)
+	if err != nil {
+		reqLogger.Error(err, "failed to create influxdb client")
+		return err
+	}
+	// always close client at the end
+	defer newClient.Close()
+
+	usersApi := newClient.UsersAPI()
+
+	var userCreated bool
+	var userAdopted bool
+	var userUpdated bool
+	var user *domain.User
+
+	// user id recorded in status is the primary way of identifying the user,
+	// lookup by name is only a fallback for objects without a recorded id
+	if len(object.Status.ID) > 0 {
+		user, err = usersApi.FindUserByID(ctx, object.Status.ID)
+		if err != nil {
+			if !isHttpStatusCode(err, 404) {
+				reqLogger.Error(err, "failed to find user by id")
+				return err
+			}
+			reqLogger.Info("user not found by id")
+			user = nil
+		}
+	}
+
+	if user == nil {
+		user, err = findUserByName(ctx, newClient, object.GetUserName())
+		if err != nil {
+			reqLogger.Error(err, "failed to find user")
+			return err
+		}
+
+		// user found by name is only taken over if it was created by this
+		// object or if adoption is explicitly requested
+		if user != nil && !hasCondition(object.Status.Conditions, reasonCreatedUser) {
+			if !object.Spec.AdoptExisting {
+				message := "influxdb user exists and is not owned by this object, set spec.adoptExisting to adopt it"
+				if object.Status.Phase != phaseConflict {
+					reqLogger.Info(message)
+					r.Recorder.Event(object, v1.EventTypeWarning, reasonConflictingUser, message)
+					object.Status.Phase = phaseConflict
+					object.Status.Message = message
+					object.Status.Reason = reasonConflictingUser
+					if err := r.Status().Update(ctx, object); err != nil {
+						reqLogger.Error(err, "failed to update object status")
+						return err
+					} else {
+						reqLogger.Info("updated object status")
+						return ObjectUpdated
+					}
+				}
+				return nil
+			}
+
+			reqLogger.Info("user adopted")
+			r.Recorder.Event(object, v1.EventTypeNormal, reasonAdoptedUser, "adopted existing influxdb user")
+			userAdopted = true
+		}
+	}
+
+	state := domain.UserStatusActive
+	if object.Spec.Inactive {
+		state = domain.UserStatusInactive
+	}
+
+	if user == nil {
+		newUser, err := usersApi.CreateUser(
+			ctx,
+			&domain.User{
+				Id:      nil,
+				Name:    object.GetUserName(),
+				OauthID: nil,
+				Status:  &state,
+			},
+		)
+		if err != nil {
+			reqLogger.Error(err, "failed to create user")
+			return err
+		}
+
+		reqLogger.Info("user created")
+		userCreated = true
+		user = newUser
+	} else if user.Name != object.GetUserName() || getUserState(user) != state {
+		// name and state are mutable on influxdb side,
+		// so bring them back in sync with the spec if they have drifted
+		user.Name = object.GetUserName()
+		user.Status = &state
+
+		updatedUser, err := usersApi.UpdateUser(ctx, user)
+		if err != nil {
+			reqLogger.Error(err, "failed to update user")
+			return err
+		}
+
+		reqLogger.Info("user updated")
+		userUpdated = true
+		user = updatedUser
+	}
+
+	if user == nil || user.Id == nil || len(*user.Id) == 0 {
+		err := fmt.Errorf("nil user pointer or invalid id")
+		reqLogger.Error(err, "failed to get valid user id")
+		return err
+	}
+
+	// record influxdb id first so that a failure further below does
+	// not result in a conflict with the user just created
+	if !hasCondition(object.Status.Conditions, reasonCreatedUser) &&
+		!hasCondition(object.Status.Conditions, reasonAdoptedUser) {
+		reason, message := reasonCreatedUser, "created influxdb user"
+		if userAdopted {
+			reason, message = reasonAdoptedUser, "adopted influxdb user"
+		}
+		object.Status.Conditions = append(object.Status.Conditions, v12.Condition{
+			Type:               conditionTypeInfluxdb,
+			Status:             v12.ConditionTrue,
+			ObservedGeneration: 0,
+			LastTransitionTime: v12.Time{Time: time.Now()},
+			Reason:             reason,
+			Message:            message,
+		})
+		object.Status.Phase = phaseReady
+		object.Status.Message = message
+		object.Status.Reason = reason
+		object.Status.ID = *user.Id
+		object.Status.State = string(getUserState(user))
+		if err := r.Status().Update(ctx, object); err != nil {
+			reqLogger.Error(err, "failed to update object status")
+			return err
+		} else {
+			reqLogger.Info("updated object status")
+			return ObjectUpdated
+		}
+	}
+
+	// password is set whenever the password secret changes
+	if object.Spec.PasswordSecret != nil && object.Status.PasswordSecretVersion != passwordVersion {
+		if err := usersApi.UpdateUserPasswordWithID(ctx, *user.Id, password); err != nil {
+			reqLogger.Error(err, "failed to set user password")
+			return err
+		}
+
+		reqLogger.Info("user password set")
+		userUpdated = true
+	}
+
+	memberships, err := getUserMemberships(ctx, r.Client, newClient, object)
+	if err != nil {
+		reqLogger.Error(err, "failed to resolve user organizations")
+		if errors.Is(err, OrganizationNotFound) || apimachineryerrors.IsNotFound(err) {
+			r.Recorder.Event(object, v1.EventTypeWarning, reasonWaitingForOrganization, err.Error())
+		}
+		return err
+	}
+
+	granted, membershipsChanged, err := reconcileUserMemberships(ctx, newClient, *user.Id, object.Status.Memberships, memberships)
+	if err != nil {
+		reqLogger.Error(err, "failed to reconcile user memberships")
+		return err
+	}
+
+	if membershipsChanged {
+		reqLogger.Info("user memberships updated")
+		userUpdated = true
+	}
+
+	status := object.Status.DeepCopy()
+	status.ID = *user.Id
+	status.State = string(getUserState(user))
+	status.PasswordSecretVersion = passwordVersion
+	status.Memberships = granted
+	if len(status.Memberships) == 0 {
+		status.Memberships = nil
+	}
+
+	if userUpdated {
+		var found bool
+		for i, condition := range status.Conditions {
+			if condition.Reason == reasonUpdatedUser {
+				status.Conditions[i].LastTransitionTime = v12.Time{Time: time.Now()}
+				found = true
+				break
+			}
+		}
+		if !found {
+			status.Conditions = append(status.Conditions, v12.Condition{
+				Type:               conditionTypeInfluxdb,
+				Status:             v12.ConditionTrue,
+				ObservedGeneration: 0,
+				LastTransitionTime: v12.Time{Time: time.Now()},
+				Reason:             reasonUpdatedUser,
+				Message:            "updated influxdb user",
+			})
+		}
+		status.Phase = phaseReady
+		status.Message = "updated influxdb user"
+		status.Reason = reasonUpdatedUser
+	}
+
+	// conflict is resolved once the user is owned by this object
+	if status.Phase == phaseConflict {
+		status.Phase = phaseReady
+		status.Message = "resolved influxdb user conflict"
+		status.Reason = reasonCreatedUser
+		if userAdopted {
+			status.Reason = reasonAdoptedUser
+		}
+	}
+
+	if userCreated || !reflect.DeepEqual(&object.Status, status) {
+		object.Status = *status
+		if err := r.Status().Update(ctx, object); err != nil {
+			reqLogger.Error(err, "failed to update object status")
+			return err
+		} else {
+			reqLogger.Info("updated object status")
+			return ObjectUpdated
+		}
+	}
+
+	return nil
+}
+
+// getUserPassword reads password of the user from the password secret
+// along with the resource version of the secret
+func getUserPassword(ctx context.Context, c client.Client, object *influxdbv1beta1.User) (string, string, error) {
+	ref := object.Spec.PasswordSecret
+
+	key := ref.Key
+	if len(key) == 0 {
+		key = keyPassword
+	}
+
+	secret := &v1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{
+		Namespace: object.Namespace,
+		Name:      ref.Name,
+	}, secret); err != nil {
+		return "", "", fmt.Errorf("failed to read password secret %s: %w", ref.Name, err)
+	}
+
+	password, ok := secret.Data[key]
+	if !ok || len(password) == 0 {
+		return "", "", fmt.Errorf("password secret %s has no or empty key %s", ref.Name, key)
+	}
+
+	return string(password), secret.ResourceVersion, nil
+}
+
+// getUserState returns user state treating nil as active
+func getUserState(user *domain.User) domain.UserStatus {
+	if user.Status == nil {
+		return domain.UserStatusActive
+	}
+
+	return *user.Status
+}
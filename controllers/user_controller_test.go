@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+	influxdbv1beta1 "github.com/kubetrail/influxdb-operator/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestUserReconcileMemberships(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeInfluxdb(t)
+	server.CreateOrg("influxdata")
+	teamId := server.CreateOrg("team")
+	opsId := server.CreateOrg("ops")
+
+	object := &influxdbv1beta1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice", Namespace: "team", UID: "alice-uid"},
+		Spec: influxdbv1beta1.UserSpec{
+			ConfigName: "admin",
+		},
+	}
+
+	r := &UserReconciler{
+		Client: newFakeClient(
+			server.Config("team", "admin", "influxdata"),
+			server.TokenSecret("team"),
+			object,
+		),
+		Scheme:                scheme.Scheme,
+		Recorder:              record.NewFakeRecorder(100),
+		Clients:               NewClientPool(),
+		DefaultDeletionPolicy: influxdbv1beta1.DeletionPolicyDelete,
+	}
+
+	getUser := func() *influxdbv1beta1.User {
+		current := getObject(t, r.Client, object)
+		if current == nil {
+			t.Fatalf("user not found")
+		}
+		return current.(*influxdbv1beta1.User)
+	}
+
+	if err := reconcileObject(t, r, r.Client, object); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	object = getUser()
+	userId := object.Status.ID
+	if server.User(userId) == nil {
+		t.Fatalf("user %q not created", userId)
+	}
+
+	// membership in ops is granted out of band
+	server.AddMember(opsId, userId)
+
+	object.Spec.Organizations = []influxdbv1beta1.UserMembership{
+		{Name: "team"},
+		{Name: "ops"},
+	}
+	if err := r.Update(ctx, object); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reconcileObject(t, r, r.Client, object); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	// only memberships granted by the user are recorded
+	object = getUser()
+	want := []influxdbv1beta1.UserMembershipStatus{
+		{OrgID: teamId, Role: influxdbv1beta1.UserRoleMember},
+	}
+	if !reflect.DeepEqual(object.Status.Memberships, want) {
+		t.Errorf("memberships = %v, want %v", object.Status.Memberships, want)
+	}
+	for _, orgId := range []string{teamId, opsId} {
+		if got := server.Members(orgId, domain.ResourceMemberRoleMember); !reflect.DeepEqual(got, []string{userId}) {
+			t.Errorf("members of %s = %v, want %s", orgId, got, userId)
+		}
+	}
+
+	// organizations removed from the spec only lose memberships granted by the user
+	object.Spec.Organizations = nil
+	if err := r.Update(ctx, object); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reconcileObject(t, r, r.Client, object); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	object = getUser()
+	if len(object.Status.Memberships) != 0 {
+		t.Errorf("memberships = %v, want none", object.Status.Memberships)
+	}
+	if got := server.Members(teamId, domain.ResourceMemberRoleMember); len(got) != 0 {
+		t.Errorf("members of team = %v, want none", got)
+	}
+	if got := server.Members(opsId, domain.ResourceMemberRoleMember); !reflect.DeepEqual(got, []string{userId}) {
+		t.Errorf("members of ops = %v, want %s", got, userId)
+	}
+}
@@ -18,7 +18,9 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -52,11 +54,28 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var defaultDeletionPolicy string
+	var secretNamespaces string
+	var protectedOrgs string
+	var protectedBuckets string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&defaultDeletionPolicy, "default-deletion-policy", influxdbv1beta1.DeletionPolicyDelete,
+		"Deletion policy for objects that do not define one. "+
+			"One of Delete, Retain or Orphan.")
+	flag.StringVar(&secretNamespaces, "cross-namespace-secrets", "",
+		"Comma separated namespaces whose Config objects may reference secrets "+
+			"in other namespaces. Use * to allow all namespaces. "+
+			"By default Config objects can only reference secrets in their own namespace.")
+	flag.StringVar(&protectedOrgs, "protected-orgs", "influxdata",
+		"Comma separated names of influxdb organizations that Organization objects "+
+			"can neither create nor delete, such as the bootstrap org.")
+	flag.StringVar(&protectedBuckets, "protected-buckets", "_*",
+		"Comma separated shell patterns matching names of influxdb buckets that "+
+			"Bucket objects can neither create nor delete.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -66,6 +85,23 @@ func main() {
 	// ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 	setupLogger()
 
+	if !influxdbv1beta1.IsValidDeletionPolicy(defaultDeletionPolicy) {
+		setupLog.Error(fmt.Errorf("invalid deletion policy %q", defaultDeletionPolicy),
+			"unable to parse flags")
+		os.Exit(1)
+	}
+
+	influxdbv1beta1.SetSecretNamespacePolicy(
+		influxdbv1beta1.NewSecretNamespacePolicy(strings.Split(secretNamespaces, ",")),
+	)
+
+	protectionPolicy, err := influxdbv1beta1.NewProtectionPolicy(protectedOrgs, protectedBuckets)
+	if err != nil {
+		setupLog.Error(err, "unable to parse flags")
+		os.Exit(1)
+	}
+	influxdbv1beta1.SetProtectionPolicy(protectionPolicy)
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -79,27 +115,59 @@ func main() {
 		os.Exit(1)
 	}
 
+	// influxdb clients are shared by all controllers
+	clients := controllers.NewClientPool()
+
 	if err = (&controllers.OrganizationReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                mgr.GetClient(),
+		Scheme:                mgr.GetScheme(),
+		Recorder:              mgr.GetEventRecorderFor("organization-controller"),
+		Clients:               clients,
+		DefaultDeletionPolicy: defaultDeletionPolicy,
+		Protection:            protectionPolicy,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Organization")
 		os.Exit(1)
 	}
 	if err = (&controllers.BucketReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                mgr.GetClient(),
+		Scheme:                mgr.GetScheme(),
+		Recorder:              mgr.GetEventRecorderFor("bucket-controller"),
+		Clients:               clients,
+		DefaultDeletionPolicy: defaultDeletionPolicy,
+		Protection:            protectionPolicy,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Bucket")
 		os.Exit(1)
 	}
 	if err = (&controllers.TokenReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                mgr.GetClient(),
+		Scheme:                mgr.GetScheme(),
+		Recorder:              mgr.GetEventRecorderFor("token-controller"),
+		Clients:               clients,
+		DefaultDeletionPolicy: defaultDeletionPolicy,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Token")
 		os.Exit(1)
 	}
+	if err = (&controllers.ConfigReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("config-controller"),
+		Clients:  clients,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Config")
+		os.Exit(1)
+	}
+	if err = (&controllers.ClusterConfigReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("clusterconfig-controller"),
+		Clients:  clients,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterConfig")
+		os.Exit(1)
+	}
 	if err = (&influxdbv1beta1.Config{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Config")
 		os.Exit(1)
@@ -116,6 +184,24 @@ func main() {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Token")
 		os.Exit(1)
 	}
+	if err = (&influxdbv1beta1.ClusterConfig{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterConfig")
+		os.Exit(1)
+	}
+	if err = (&controllers.UserReconciler{
+		Client:                mgr.GetClient(),
+		Scheme:                mgr.GetScheme(),
+		Recorder:              mgr.GetEventRecorderFor("user-controller"),
+		Clients:               clients,
+		DefaultDeletionPolicy: defaultDeletionPolicy,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "User")
+		os.Exit(1)
+	}
+	if err = (&influxdbv1beta1.User{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "User")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {